@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testAdmin(t *testing.T, l interface {
+	RateLimiter
+	Admin
+}, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Period: time.Minute, Burst: 3}
+
+	t.Run("Reset", func(t *testing.T) {
+		id := newID("reset")
+		if _, err := l.AllowN(ctx, id, limit, 3); err != nil {
+			t.Fatal(err)
+		}
+		if dec, _ := l.Allow(ctx, id, limit); dec.Allow {
+			t.Fatal("Expected bucket to be exhausted")
+		}
+
+		if err := l.Reset(ctx, id); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+
+		dec, err := l.Allow(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dec.Allow || dec.Remaining != 2 {
+			t.Errorf("Expected a full bucket after Reset, got %+v", dec)
+		}
+	})
+
+	t.Run("Refund", func(t *testing.T) {
+		id := newID("refund")
+		if _, err := l.AllowN(ctx, id, limit, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := l.Refund(ctx, id, limit, 2); err != nil {
+			t.Fatalf("Refund failed: %v", err)
+		}
+		dec, err := l.AllowN(ctx, id, limit, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dec.Allow {
+			t.Error("Expected refunded tokens to be spendable")
+		}
+	})
+
+	t.Run("RefundCappedAtBurst", func(t *testing.T) {
+		id := newID("refund_cap")
+		if _, err := l.Allow(ctx, id, limit); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := l.Refund(ctx, id, limit, 100); err != nil {
+			t.Fatalf("Refund failed: %v", err)
+		}
+		dec, err := l.Allow(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Remaining != limit.Burst-1 {
+			t.Errorf("Expected refund to be capped at Burst, got %d remaining", dec.Remaining)
+		}
+	})
+
+	t.Run("InvalidRefund", func(t *testing.T) {
+		if err := l.Refund(ctx, newID("refund_invalid"), limit, 0); !errors.Is(err, ErrInvalidCost) {
+			t.Errorf("Expected ErrInvalidCost, got %v", err)
+		}
+	})
+}
+
+func TestMemoryLimiter_Admin(t *testing.T) {
+	testAdmin(t, NewMemoryLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisLimiter_Admin(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testAdmin(t, limiter, uniqueIdentity)
+}
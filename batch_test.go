@@ -0,0 +1,72 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testBatchLimiter(t *testing.T, l BatchLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Period: time.Minute, Burst: 2}
+
+	ip := newID("ip")
+	apiKey := newID("api_key")
+	checks := []Check{
+		{ID: ip, Limit: limit},
+		{ID: apiKey, Limit: limit, Cost: 2},
+		{ID: newID("route"), Limit: limit, Cost: 3},
+	}
+
+	results := l.AllowMany(ctx, checks)
+	if len(results) != len(checks) {
+		t.Fatalf("Expected %d results, got %d", len(checks), len(results))
+	}
+	if results[0].Err != nil || !results[0].Decision.Allow || results[0].Decision.Remaining != 1 {
+		t.Errorf("Expected ip check allowed with 1 remaining, got %+v", results[0])
+	}
+	if results[1].Err != nil || !results[1].Decision.Allow || results[1].Decision.Remaining != 0 {
+		t.Errorf("Expected api key check allowed with 0 remaining, got %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, ErrCostExceedsBurst) {
+		t.Errorf("Expected ErrCostExceedsBurst for the route check, got %+v", results[2])
+	}
+
+	// Unlike AllowAll, checks are independent: the exhausted api key does not
+	// stop the ip from being admitted.
+	results = l.AllowMany(ctx, []Check{{ID: ip, Limit: limit}, {ID: apiKey, Limit: limit}})
+	if !results[0].Decision.Allow {
+		t.Error("Expected the ip check to be allowed")
+	}
+	if results[1].Decision.Allow || results[1].Decision.RetryAfter <= 0 {
+		t.Errorf("Expected the api key check to be denied with a RetryAfter, got %+v", results[1])
+	}
+}
+
+func TestMemoryLimiter_AllowMany(t *testing.T) {
+	testBatchLimiter(t, NewMemoryLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisLimiter_AllowMany(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testBatchLimiter(t, limiter, uniqueIdentity)
+}
+
+func BenchmarkMemoryLimiter_AllowMany(b *testing.B) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter()
+
+	limit := Limit{Rate: 1000, Burst: 100000, Period: time.Second}
+	checks := []Check{
+		{ID: Identity{Namespace: "ip", Key: "10.0.0.1"}, Limit: limit},
+		{ID: Identity{Namespace: "api_key", Key: "key_1"}, Limit: limit},
+		{ID: Identity{Namespace: "route", Key: "GET /v1/items"}, Limit: limit},
+	}
+
+	for b.Loop() {
+		limiter.AllowMany(ctx, checks)
+	}
+}
@@ -0,0 +1,247 @@
+// Package clientip works out which client an HTTP request came from, for use
+// as a rate limit key.
+//
+// r.RemoteAddr is the right answer only when clients connect directly: it
+// includes an ephemeral port, and behind a load balancer it is the balancer's
+// address. An Extractor strips the port and, when the connection comes from a
+// trusted proxy, walks the X-Forwarded-For (or Forwarded) chain from the
+// right, skipping trusted proxies, to the first address it cannot vouch for.
+// Entries left of that address are ignored, since the client could have
+// written them.
+//
+// IPv6 hosts usually control a whole /64, so by default IPv6 addresses are
+// aggregated to their /64 and one host cannot evade a limit by rotating
+// addresses:
+//
+//	ex, err := clientip.New(
+//		clientip.WithTrustedProxies("10.0.0.0/8", "fd00::/8"),
+//		clientip.WithIPv6Prefix(64),
+//	)
+//	mw, err := middleware.New(l, ex.Identity, middleware.WithLimit(limit))
+package clientip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/middleware"
+)
+
+// ErrNoAddress is returned when a request's remote address cannot be parsed.
+// It wraps middleware.ErrNoKey, so the middleware answers such requests with
+// 400 Bad Request rather than 503.
+var ErrNoAddress = fmt.Errorf("clientip: no usable address: %w", middleware.ErrNoKey)
+
+// Header names the header trusted proxies report the client chain in.
+type Header string
+
+const (
+	// XForwardedFor reads the de facto "X-Forwarded-For: client, proxy1"
+	// list.
+	XForwardedFor Header = "X-Forwarded-For"
+	// Forwarded reads the for= parameters of the RFC 7239 Forwarded header.
+	Forwarded Header = "Forwarded"
+)
+
+// Extractor finds the client address of requests. It is safe for concurrent
+// use.
+type Extractor struct {
+	trusted    []netip.Prefix
+	header     Header
+	ipv4Prefix int
+	ipv6Prefix int
+	err        error
+}
+
+// Option configures an Extractor.
+type Option func(*Extractor)
+
+// WithTrustedProxies sets the proxies, as CIDRs or single addresses, whose
+// forwarding headers are believed. Without any, headers are ignored and the
+// peer address is the client.
+func WithTrustedProxies(cidrs ...string) Option {
+	return func(e *Extractor) {
+		for _, cidr := range cidrs {
+			p, err := parsePrefix(strings.TrimSpace(cidr))
+			if err != nil {
+				e.err = errors.Join(e.err, err)
+				continue
+			}
+			e.trusted = append(e.trusted, p)
+		}
+	}
+}
+
+// WithHeader selects the header trusted proxies use (default XForwardedFor).
+// Only one is read, so a client cannot smuggle addresses in the other.
+func WithHeader(h Header) Option {
+	return func(e *Extractor) {
+		e.header = h
+	}
+}
+
+// WithIPv4Prefix aggregates IPv4 clients to their /bits network (default 32,
+// one key per address).
+func WithIPv4Prefix(bits int) Option {
+	return func(e *Extractor) {
+		e.ipv4Prefix = bits
+	}
+}
+
+// WithIPv6Prefix aggregates IPv6 clients to their /bits network (default 64).
+// Use 128 for one key per address.
+func WithIPv6Prefix(bits int) Option {
+	return func(e *Extractor) {
+		e.ipv6Prefix = bits
+	}
+}
+
+// New builds an Extractor. It fails if a trusted proxy is not a valid CIDR or
+// address, or a prefix length is out of range.
+func New(opts ...Option) (*Extractor, error) {
+	e := &Extractor{
+		header:     XForwardedFor,
+		ipv4Prefix: 32,
+		ipv6Prefix: 64,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	if e.header != XForwardedFor && e.header != Forwarded {
+		return nil, fmt.Errorf("clientip: unsupported header %q", e.header)
+	}
+	if e.ipv4Prefix < 1 || e.ipv4Prefix > 32 {
+		return nil, fmt.Errorf("clientip: IPv4 prefix must be between 1 and 32, got %d", e.ipv4Prefix)
+	}
+	if e.ipv6Prefix < 1 || e.ipv6Prefix > 128 {
+		return nil, fmt.Errorf("clientip: IPv6 prefix must be between 1 and 128, got %d", e.ipv6Prefix)
+	}
+	return e, nil
+}
+
+// ClientIP returns the address of the client that sent r, without
+// aggregation.
+func (e *Extractor) ClientIP(r *http.Request) (netip.Addr, error) {
+	addr, err := parseAddr(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: remote address %q", ErrNoAddress, r.RemoteAddr)
+	}
+
+	chain := e.chain(r)
+	for i := len(chain) - 1; i >= 0 && e.isTrusted(addr); i-- {
+		hop, err := parseAddr(chain[i])
+		if err != nil {
+			// A trusted proxy sent something unusable ("unknown", an
+			// obfuscated node); it is the best address we have.
+			break
+		}
+		addr = hop
+	}
+	return addr, nil
+}
+
+// Key returns the rate limit key of the client that sent r: its address, or
+// the network it is aggregated to, such as "2001:db8:1:2::/64".
+func (e *Extractor) Key(r *http.Request) (string, error) {
+	addr, err := e.ClientIP(r)
+	if err != nil {
+		return "", err
+	}
+
+	bits := e.ipv6Prefix
+	if addr.Is4() {
+		bits = e.ipv4Prefix
+	}
+	if bits == addr.BitLen() {
+		return addr.String(), nil
+	}
+	return netip.PrefixFrom(addr, bits).Masked().String(), nil
+}
+
+// Identity returns the client's Key in the "ip" namespace. Its signature
+// matches middleware.KeyFunc, so ex.Identity can be passed to middleware.New.
+func (e *Extractor) Identity(r *http.Request) (limiter.Identity, error) {
+	key, err := e.Key(r)
+	if err != nil {
+		return limiter.Identity{}, err
+	}
+	return limiter.Identity{Namespace: "ip", Key: key}, nil
+}
+
+// isTrusted reports whether addr is one of the trusted proxies.
+func (e *Extractor) isTrusted(addr netip.Addr) bool {
+	for _, p := range e.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// chain returns the client chain reported in the configured header, leftmost
+// (furthest from us) first, across all lines of the header.
+func (e *Extractor) chain(r *http.Request) []string {
+	var chain []string
+	for _, line := range r.Header.Values(string(e.header)) {
+		for _, elem := range strings.Split(line, ",") {
+			if e.header == Forwarded {
+				chain = append(chain, forwardedFor(elem))
+			} else {
+				chain = append(chain, strings.TrimSpace(elem))
+			}
+		}
+	}
+	return chain
+}
+
+// forwardedFor returns the for= value of one Forwarded element, such as
+// `for="[2001:db8::1]:4711";proto=https`, or "" if it has none.
+func forwardedFor(elem string) string {
+	for _, pair := range strings.Split(elem, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.EqualFold(name, "for") {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// parseAddr parses an address that may carry a port ("192.0.2.1:80",
+// "[2001:db8::1]:80") or brackets, dropping any IPv6 zone and unmapping
+// IPv4-mapped IPv6 addresses.
+func parseAddr(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.WithZone("").Unmap(), nil
+}
+
+// parsePrefix parses a CIDR, or a single address as a full-length prefix.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("clientip: invalid trusted proxy %q: %w", s, err)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := parseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("clientip: invalid trusted proxy %q: %w", s, err)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
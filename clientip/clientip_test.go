@@ -0,0 +1,207 @@
+package clientip
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/middleware"
+)
+
+func request(remoteAddr string, header http.Header) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	for name, values := range header {
+		for _, v := range values {
+			r.Header.Add(name, v)
+		}
+	}
+	return r
+}
+
+func TestExtractor_Key(t *testing.T) {
+	proxied, err := New(WithTrustedProxies("10.0.0.0/8", "192.0.2.1", "fd00::/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forwarded, err := New(WithTrustedProxies("10.0.0.0/8"), WithHeader(Forwarded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact, err := New(WithIPv6Prefix(128))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ex         *Extractor
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "StripsPort",
+			ex:         direct,
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "IgnoresHeadersFromUntrustedPeer",
+			ex:         direct,
+			remoteAddr: "203.0.113.7:51234",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "TrustedProxy",
+			ex:         proxied,
+			remoteAddr: "10.1.2.3:443",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "SkipsTrustedHops",
+			ex:         proxied,
+			remoteAddr: "10.1.2.3:443",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1, 192.0.2.1", "10.9.9.9"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "IgnoresSpoofedEntries",
+			ex:         proxied,
+			remoteAddr: "10.1.2.3:443",
+			header:     http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "AllTrustedUsesLeftmost",
+			ex:         proxied,
+			remoteAddr: "10.1.2.3:443",
+			header:     http.Header{"X-Forwarded-For": {"10.0.0.5, 10.0.0.6"}},
+			want:       "10.0.0.5",
+		},
+		{
+			name:       "UnusableHopStops",
+			ex:         proxied,
+			remoteAddr: "10.1.2.3:443",
+			header:     http.Header{"X-Forwarded-For": {"198.51.100.1, unknown"}},
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "NoHeaderUsesPeer",
+			ex:         proxied,
+			remoteAddr: "10.1.2.3:443",
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "ForwardedHeader",
+			ex:         forwarded,
+			remoteAddr: "10.1.2.3:443",
+			header: http.Header{
+				"Forwarded":       {`for=198.51.100.1;proto=https, for="[2001:db8:aa:bb::1]:4711"`},
+				"X-Forwarded-For": {"203.0.113.99"},
+			},
+			want: "2001:db8:aa:bb::/64",
+		},
+		{
+			name:       "IPv6AggregatedToSlash64",
+			ex:         direct,
+			remoteAddr: "[2001:db8:1:2:3:4:5:6]:443",
+			want:       "2001:db8:1:2::/64",
+		},
+		{
+			name:       "IPv6Exact",
+			ex:         exact,
+			remoteAddr: "[2001:db8:1:2:3:4:5:6]:443",
+			want:       "2001:db8:1:2:3:4:5:6",
+		},
+		{
+			name:       "IPv4MappedIPv6",
+			ex:         direct,
+			remoteAddr: "[::ffff:203.0.113.7]:443",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "TrustedIPv6Proxy",
+			ex:         proxied,
+			remoteAddr: "[fd00::1]:443",
+			header:     http.Header{"X-Forwarded-For": {"[2001:db8:5:6::9]:8080"}},
+			want:       "2001:db8:5:6::/64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ex.Key(request(tt.remoteAddr, tt.header))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExtractor_IPv4Prefix(t *testing.T) {
+	ex, err := New(WithIPv4Prefix(24))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ex.Identity(request("203.0.113.7:1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (limiter.Identity{Namespace: "ip", Key: "203.0.113.0/24"}); id != want {
+		t.Errorf("Expected %v, got %v", want, id)
+	}
+}
+
+func TestExtractor_BadRemoteAddr(t *testing.T) {
+	ex, _ := New()
+	if _, err := ex.Identity(request("pipe", nil)); !errors.Is(err, ErrNoAddress) {
+		t.Errorf("Expected ErrNoAddress, got %v", err)
+	}
+}
+
+func TestExtractor_EmptyRemoteAddr(t *testing.T) {
+	ex, _ := New()
+	_, err := ex.Identity(request("", nil))
+	if !errors.Is(err, ErrNoAddress) || !errors.Is(err, middleware.ErrNoKey) {
+		t.Fatalf("Expected ErrNoAddress wrapping middleware.ErrNoKey, got %v", err)
+	}
+
+	mw, err := middleware.New(limiter.NewMemoryLimiter(), ex.Identity,
+		middleware.WithLimit(limiter.Limit{Rate: 1, Period: time.Second, Burst: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	mw.Handler(http.NotFoundHandler()).ServeHTTP(rec, request("", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestNew_Validates(t *testing.T) {
+	tests := map[string][]Option{
+		"BadCIDR":       {WithTrustedProxies("10.0.0.0/33")},
+		"BadAddress":    {WithTrustedProxies("proxy.internal")},
+		"BadHeader":     {WithHeader("X-Real-IP")},
+		"BadIPv4Prefix": {WithIPv4Prefix(0)},
+		"BadIPv6Prefix": {WithIPv6Prefix(129)},
+	}
+	for name, opts := range tests {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
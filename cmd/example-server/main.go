@@ -1,13 +1,16 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/clientip"
+	"github.com/manenim/gateway-rate-limiter/headers"
+	"github.com/manenim/gateway-rate-limiter/middleware"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -28,28 +31,39 @@ func main() {
 		log.Fatal(err)
 	}
 
-	http.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-
-		// Rate Limit: 5 req/sec (Burst 10) per IP
-		ip := r.RemoteAddr
-		id := limiter.Identity{Namespace: "ip", Key: ip}
-		limit := limiter.Limit{Rate: 5, Period: time.Second, Burst: 10}
-
-		dec, err := l.Allow(ctx, id, limit)
-		if err != nil {
-			// Fail Open or Closed? Here we Fail Open (allow traffic on error)
-			log.Printf("Limiter error: %v", err)
-		} else if !dec.Allow {
-			w.Header().Set("Retry-After", fmt.Sprintf("%.2f", dec.RetryAfter.Seconds()))
-			w.WriteHeader(http.StatusTooManyRequests)
-			w.Write([]byte("Rate limit exceeded\n"))
-			return
-		}
+	// RATELIMIT_HEADERS picks the header style: "ietf", "legacy",
+	// "ietf,legacy" or "none" (Retry-After only).
+	style, err := headers.ParseStyle(os.Getenv("RATELIMIT_HEADERS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// TRUSTED_PROXIES lists the load balancers (comma-separated CIDRs) whose
+	// X-Forwarded-For is believed. IPv6 clients are limited per /64.
+	var trusted []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		trusted = strings.Split(v, ",")
+	}
+	ips, err := clientip.New(clientip.WithTrustedProxies(trusted...))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Rate Limit: 5 req/sec (Burst 10) per IP. Limiter errors fail open
+	// (allow traffic), which is the middleware's default.
+	mw, err := middleware.New(l, ips.Identity,
+		middleware.WithLimit(limiter.Limit{Rate: 5, Period: time.Second, Burst: 10}),
+		middleware.WithHeaders(headers.Writer{Style: style, Policy: "ping"}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("Pong!\n"))
 	})
 
 	log.Printf("Server listening on :8080 (Redis: %s)", redisAddr)
-	http.ListenAndServe(":8080", nil)
+	http.ListenAndServe(":8080", mw.Handler(mux))
 }
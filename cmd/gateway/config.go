@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/policy"
+)
+
+// Config is the layout of the gateway's JSON configuration file.
+type Config struct {
+	// Listen is the address the gateway serves on (default ":8080").
+	Listen string `json:"listen,omitempty"`
+	// AdminListen, if set, serves /healthz, /readyz and /metrics on a
+	// separate address instead of the main one.
+	AdminListen string `json:"admin_listen,omitempty"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// on shutdown (default "10s").
+	ShutdownTimeout policy.Duration `json:"shutdown_timeout,omitempty"`
+
+	// Redis selects RedisLimiter; without it the gateway uses a
+	// MemoryLimiter, which only limits per gateway instance.
+	Redis *RedisConfig `json:"redis,omitempty"`
+
+	Identity IdentityConfig `json:"identity"`
+	// Headers is the rate limit header style: "ietf", "legacy",
+	// "ietf,legacy" or "none" (Retry-After only).
+	Headers string `json:"headers,omitempty"`
+	// FailClosed rejects requests with 503 when the limiter is unavailable,
+	// instead of proxying them.
+	FailClosed bool `json:"fail_closed,omitempty"`
+
+	Upstreams []UpstreamConfig `json:"upstreams"`
+	// Routes holds per-route limits, keyed by ServeMux pattern.
+	Routes []RouteConfig `json:"routes,omitempty"`
+	// DefaultLimit applies to requests that match no route. Without it they
+	// are not limited.
+	DefaultLimit *LimitConfig `json:"default_limit,omitempty"`
+}
+
+// RedisConfig configures the Redis backend.
+type RedisConfig struct {
+	Addr     string `json:"addr"`
+	Password string `json:"password,omitempty"`
+	DB       int    `json:"db,omitempty"`
+	// Prefix defaults to "gateway:".
+	Prefix string `json:"prefix,omitempty"`
+	// Timeout bounds each Redis read and write made while serving requests
+	// (default "100ms"). Start-up uses the limiter's own, longer timeout.
+	Timeout policy.Duration `json:"timeout,omitempty"`
+}
+
+// IdentityConfig selects who requests are limited as.
+type IdentityConfig struct {
+	// Source is "ip" (the default), "header" or "api_key".
+	Source string `json:"source,omitempty"`
+	// Header is read for the "header" and "api_key" sources.
+	Header string `json:"header,omitempty"`
+	// Namespace names the identity for the "header" source (default
+	// "header").
+	Namespace limiter.Namespace `json:"namespace,omitempty"`
+	// TrustedProxies lists the CIDRs whose X-Forwarded-For is believed for
+	// the "ip" source.
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+	// IPv6Prefix aggregates IPv6 clients for the "ip" source (default 64).
+	IPv6Prefix int `json:"ipv6_prefix,omitempty"`
+}
+
+// UpstreamConfig proxies requests matching Pattern to URL.
+type UpstreamConfig struct {
+	// Pattern is a ServeMux pattern such as "/orders/" or
+	// "api.example.com/".
+	Pattern string `json:"pattern"`
+	URL     string `json:"url"`
+	// StripPrefix is removed from the request path before proxying.
+	StripPrefix string `json:"strip_prefix,omitempty"`
+}
+
+// RouteConfig is the limit of one ServeMux pattern.
+type RouteConfig struct {
+	Pattern string `json:"pattern"`
+	LimitConfig
+}
+
+// LimitConfig is a limiter.Limit with a JSON-friendly period. Burst defaults
+// to Rate.
+type LimitConfig struct {
+	Rate   int64           `json:"rate"`
+	Period policy.Duration `json:"period"`
+	Burst  int64           `json:"burst,omitempty"`
+}
+
+// Limit returns the limit with its defaults applied.
+func (c LimitConfig) Limit() limiter.Limit {
+	burst := c.Burst
+	if burst == 0 {
+		burst = c.Rate
+	}
+	return limiter.Limit{Rate: c.Rate, Period: time.Duration(c.Period), Burst: burst}
+}
+
+// LoadConfig reads and validates the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes and validates a configuration, applying defaults.
+// Unknown fields are rejected, so typos do not silently fall back to
+// defaults.
+func ParseConfig(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("gateway: parse config: %w", err)
+	}
+	cfg.applyDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("gateway: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.Listen == "" {
+		c.Listen = ":8080"
+	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = policy.Duration(10 * time.Second)
+	}
+	if c.Redis != nil {
+		if c.Redis.Prefix == "" {
+			c.Redis.Prefix = "gateway:"
+		}
+		if c.Redis.Timeout == 0 {
+			c.Redis.Timeout = policy.Duration(100 * time.Millisecond)
+		}
+	}
+	if c.Identity.Source == "" {
+		c.Identity.Source = "ip"
+	}
+	if c.Identity.Namespace == "" {
+		c.Identity.Namespace = "header"
+	}
+	if c.Identity.IPv6Prefix == 0 {
+		c.Identity.IPv6Prefix = 64
+	}
+}
+
+// validate checks what can be checked without building the gateway; route
+// patterns, proxies and header styles are checked when they are built.
+func (c *Config) validate() error {
+	if c.Redis != nil && c.Redis.Addr == "" {
+		return errors.New("redis: addr is required")
+	}
+
+	switch c.Identity.Source {
+	case "ip":
+	case "header", "api_key":
+		if c.Identity.Header == "" {
+			return fmt.Errorf("identity: source %q needs a header", c.Identity.Source)
+		}
+	default:
+		return fmt.Errorf("identity: unknown source %q", c.Identity.Source)
+	}
+
+	if len(c.Upstreams) == 0 {
+		return errors.New("at least one upstream is required")
+	}
+	for _, u := range c.Upstreams {
+		target, err := url.Parse(u.URL)
+		if err != nil {
+			return fmt.Errorf("upstream %q: %w", u.Pattern, err)
+		}
+		if target.Scheme == "" || target.Host == "" {
+			return fmt.Errorf("upstream %q: url %q must be absolute", u.Pattern, u.URL)
+		}
+	}
+
+	if c.DefaultLimit != nil {
+		if err := c.DefaultLimit.Limit().Validate(); err != nil {
+			return fmt.Errorf("default_limit: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/clientip"
+	"github.com/manenim/gateway-rate-limiter/headers"
+	"github.com/manenim/gateway-rate-limiter/middleware"
+)
+
+// newGateway builds the rate limited reverse proxy described by cfg, and the
+// handler for its health and metrics endpoints. ready reports whether the
+// limiter's backend is reachable.
+func newGateway(cfg *Config, l limiter.RateLimiter, m *metrics, ready func(context.Context) error) (proxy, admin http.Handler, err error) {
+	upstreams := http.NewServeMux()
+	for _, u := range cfg.Upstreams {
+		target, _ := url.Parse(u.URL) // validated by ParseConfig
+		var h http.Handler = newProxy(target)
+		if u.StripPrefix != "" {
+			h = http.StripPrefix(u.StripPrefix, h)
+		}
+		if err := handle(upstreams, u.Pattern, h); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	key, err := newKeyFunc(cfg.Identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	style, err := headers.ParseStyle(cfg.Headers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	routeLimits := make(map[string]limiter.Limit, len(cfg.Routes))
+	for _, r := range cfg.Routes {
+		routeLimits[r.Pattern] = r.Limit()
+	}
+	routes, err := middleware.NewRoutes(routeLimits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := []middleware.Option{
+		middleware.WithRoutes(routes),
+		middleware.WithHeaders(headers.Writer{Style: style}),
+		middleware.WithDenyHandler(func(w http.ResponseWriter, r *http.Request, dec limiter.Decision) {
+			m.Add("gateway.denied", 1, nil)
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		}),
+	}
+	if cfg.DefaultLimit != nil {
+		opts = append(opts, middleware.WithLimit(cfg.DefaultLimit.Limit()))
+	}
+	if cfg.FailClosed {
+		opts = append(opts, middleware.WithFailClosed())
+	}
+	mw, err := middleware.New(l, key, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	adminMux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := ready(r.Context()); err != nil {
+			http.Error(w, "limiter backend unavailable: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	adminMux.Handle("GET /metrics", m)
+
+	return countResponses(m, mw.Handler(upstreams)), adminMux, nil
+}
+
+// newProxy forwards requests to target, setting the X-Forwarded-* headers.
+func newProxy(target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Host = pr.In.Host
+			pr.SetXForwarded()
+		},
+	}
+}
+
+// handle registers h on mux, turning the panic of an invalid or conflicting
+// pattern into an error.
+func handle(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("upstream %q: %v", pattern, r)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
+
+// newKeyFunc builds the KeyFunc for the configured identity source.
+func newKeyFunc(cfg IdentityConfig) (middleware.KeyFunc, error) {
+	switch cfg.Source {
+	case "header":
+		return middleware.Header(cfg.Header, cfg.Namespace), nil
+	case "api_key":
+		return middleware.APIKey(cfg.Header), nil
+	default:
+		ips, err := clientip.New(
+			clientip.WithTrustedProxies(cfg.TrustedProxies...),
+			clientip.WithIPv6Prefix(cfg.IPv6Prefix),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return ips.Identity, nil
+	}
+}
+
+// countResponses counts responses by status code as gateway.responses.
+func countResponses(m *metrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		m.Add("gateway.responses", 1, map[string]string{"code": strconv.Itoa(sw.status)})
+	})
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.status, w.wrote = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streaming responses can still be flushed through the proxy.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
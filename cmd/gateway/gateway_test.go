@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+func TestLoadConfig_Example(t *testing.T) {
+	cfg, err := LoadConfig("gateway.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redis == nil || cfg.Redis.Addr != "localhost:6379" {
+		t.Errorf("Expected the Redis backend, got %+v", cfg.Redis)
+	}
+	if got := cfg.Routes[1].Limit(); got.Burst != 100 {
+		t.Errorf("Expected burst to default to rate, got %+v", got)
+	}
+	if _, _, err := newGateway(cfg, limiter.NewMemoryLimiter(), newMetrics(), nil); err != nil {
+		t.Errorf("Expected the example to build, got %v", err)
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"UnknownField":   `{"upstreams": [{"pattern": "/", "url": "http://a"}], "limts": []}`,
+		"NoUpstreams":    `{}`,
+		"RelativeURL":    `{"upstreams": [{"pattern": "/", "url": "a:80"}]}`,
+		"HeaderNoName":   `{"identity": {"source": "header"}, "upstreams": [{"pattern": "/", "url": "http://a"}]}`,
+		"UnknownSource":  `{"identity": {"source": "cookie"}, "upstreams": [{"pattern": "/", "url": "http://a"}]}`,
+		"BadDefault":     `{"default_limit": {"rate": 1}, "upstreams": [{"pattern": "/", "url": "http://a"}]}`,
+		"RedisNoAddress": `{"redis": {}, "upstreams": [{"pattern": "/", "url": "http://a"}]}`,
+	}
+	for name, data := range tests {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGateway(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "upstream %s %s", r.Method, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	cfg, err := ParseConfig([]byte(`{
+		"headers": "legacy",
+		"upstreams": [{"pattern": "/api/", "url": "` + upstream.URL + `", "strip_prefix": "/api"}],
+		"routes": [{"pattern": "POST /api/orders/{id}", "rate": 1, "period": "1m", "burst": 2}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newMetrics()
+	proxy, admin, err := newGateway(cfg, limiter.NewMemoryLimiter(), m, func(context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send(http.MethodPost, "/api/orders/1")
+	if w.Code != http.StatusOK || w.Body.String() != "upstream POST /orders/1" {
+		t.Fatalf("Expected the request to be proxied, got %d %q", w.Code, w.Body)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "1" {
+		t.Errorf("Expected X-RateLimit-Remaining 1, got %q", got)
+	}
+
+	send(http.MethodPost, "/api/orders/2")
+	if w := send(http.MethodPost, "/api/orders/3"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the route limit to be shared across orders, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/api/orders/1"); w.Code != http.StatusOK {
+		t.Errorf("Expected an unlimited route to be proxied, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/elsewhere"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without an upstream, got %d", w.Code)
+	}
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected %s to be ok, got %d", path, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+	for _, want := range []string{
+		"# TYPE gateway_denied_total counter\ngateway_denied_total 1\n",
+		`gateway_responses_total{code="200"} 3`,
+		`gateway_responses_total{code="429"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
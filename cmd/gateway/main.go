@@ -0,0 +1,123 @@
+// Command gateway is a rate limiting reverse proxy. It forwards requests to
+// the upstreams in its configuration file, applying per-route limits with
+// RedisLimiter (shared across gateway instances) or MemoryLimiter.
+//
+// Usage:
+//
+//	gateway -config gateway.json
+//
+// See gateway.example.json for the configuration format. The gateway serves
+// /healthz, /readyz and /metrics (Prometheus text format) on its admin
+// address, and finishes in-flight requests before exiting on SIGINT or
+// SIGTERM.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	configPath := flag.String("config", "gateway.json", "path to the JSON configuration file")
+	flag.Parse()
+
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the gateway until ctx is done, then shuts it down gracefully.
+func run(ctx context.Context, cfg *Config) error {
+	m := newMetrics()
+
+	var (
+		l     limiter.RateLimiter
+		ready = func(context.Context) error { return nil }
+	)
+	if cfg.Redis != nil {
+		client := redis.NewClient(&redis.Options{
+			Addr:         cfg.Redis.Addr,
+			Password:     cfg.Redis.Password,
+			DB:           cfg.Redis.DB,
+			ReadTimeout:  time.Duration(cfg.Redis.Timeout),
+			WriteTimeout: time.Duration(cfg.Redis.Timeout),
+		})
+		defer client.Close()
+
+		rl, err := limiter.NewRedisLimiter(client,
+			limiter.WithPrefix(cfg.Redis.Prefix),
+			limiter.WithRecorder(m),
+		)
+		if err != nil {
+			return err
+		}
+		l = rl
+		ready = func(ctx context.Context) error { return client.Ping(ctx).Err() }
+		log.Printf("Using Redis at %s", cfg.Redis.Addr)
+	} else {
+		l = limiter.NewMemoryLimiter()
+		log.Print("Using in-memory limiter (limits are per instance)")
+	}
+
+	proxy, admin, err := newGateway(cfg, l, m, ready)
+	if err != nil {
+		return err
+	}
+
+	servers := []*http.Server{{Addr: cfg.Listen, Handler: proxy}}
+	if cfg.AdminListen != "" {
+		servers = append(servers, &http.Server{Addr: cfg.AdminListen, Handler: admin})
+	} else {
+		// Exact admin paths take precedence over the upstreams' patterns.
+		mux := http.NewServeMux()
+		mux.Handle("/", proxy)
+		mux.Handle("GET /healthz", admin)
+		mux.Handle("GET /readyz", admin)
+		mux.Handle("GET /metrics", admin)
+		servers[0].Handler = mux
+	}
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			log.Printf("Listening on %s", srv.Addr)
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+
+	var shutdownErr error
+	for _, srv := range servers {
+		shutdownErr = errors.Join(shutdownErr, srv.Shutdown(shutdownCtx))
+	}
+	return shutdownErr
+}
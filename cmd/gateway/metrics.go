@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// metrics is a limiter.MetricsRecorder that keeps counters and summaries in
+// memory and serves them in the Prometheus text format. Metric names have
+// their dots replaced by underscores: "ratelimit.call" becomes
+// "ratelimit_call_total".
+type metrics struct {
+	mu       sync.Mutex
+	counters map[string]map[string]float64
+	sums     map[string]map[string]float64
+	counts   map[string]map[string]float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		counters: make(map[string]map[string]float64),
+		sums:     make(map[string]map[string]float64),
+		counts:   make(map[string]map[string]float64),
+	}
+}
+
+// Add increments the counter name{tags}.
+func (m *metrics) Add(name string, value float64, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	add(m.counters, name, labels(tags), value)
+}
+
+// Observe records value in the summary name{tags}, as a sum and a count.
+func (m *metrics) Observe(name string, value float64, tags map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l := labels(tags)
+	add(m.sums, name, l, value)
+	add(m.counts, name, l, 1)
+}
+
+func add(series map[string]map[string]float64, name, labels string, value float64) {
+	if series[name] == nil {
+		series[name] = make(map[string]float64)
+	}
+	series[name][labels] += value
+}
+
+// ServeHTTP writes every metric in the Prometheus text format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(m.counters)) {
+		metric := promName(name) + "_total"
+		fmt.Fprintf(w, "# TYPE %s counter\n", metric)
+		writeSeries(w, metric, m.counters[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.sums)) {
+		metric := promName(name)
+		fmt.Fprintf(w, "# TYPE %s summary\n", metric)
+		writeSeries(w, metric+"_sum", m.sums[name])
+		writeSeries(w, metric+"_count", m.counts[name])
+	}
+}
+
+func writeSeries(w io.Writer, metric string, series map[string]float64) {
+	for _, l := range slices.Sorted(maps.Keys(series)) {
+		fmt.Fprintf(w, "%s%s %g\n", metric, l, series[l])
+	}
+}
+
+// labels renders tags as a sorted Prometheus label set such as
+// `{namespace="ip",status="allowed"}`, or "" if there are none.
+func labels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(tags))
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", promName(k), tags[k]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// promName replaces the characters Prometheus does not allow in names.
+func promName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
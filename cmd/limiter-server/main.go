@@ -0,0 +1,122 @@
+// Command limiter-server serves rate limit decisions over HTTP/JSON so that
+// services in any language can share the same buckets. See package remote
+// for the API; Go services can use remote.Client in place of an embedded
+// limiter.
+//
+// Usage:
+//
+//	limiter-server -addr :8081 -redis localhost:6379
+//	limiter-server -addr "" -unix /run/limiter.sock
+//
+// Without -redis the limiter is in memory, so every client must talk to the
+// same instance. The server finishes in-flight requests before exiting on
+// SIGINT or SIGTERM.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/remote"
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "TCP address to listen on; empty to disable")
+	unixPath := flag.String("unix", "", "Unix socket path to listen on; empty to disable")
+	redisAddr := flag.String("redis", "", "Redis address; empty for an in-memory limiter")
+	prefix := flag.String("prefix", "", `prefix for Redis keys (default the library's "limiter:")`)
+	timeout := flag.Duration("timeout", 100*time.Millisecond, "timeout for each Redis read and write")
+	flag.Parse()
+
+	if *addr == "" && *unixPath == "" {
+		log.Fatal("at least one of -addr and -unix is required")
+	}
+
+	var l limiter.RateLimiter
+	if *redisAddr != "" {
+		client := redis.NewClient(&redis.Options{
+			Addr:         *redisAddr,
+			ReadTimeout:  *timeout,
+			WriteTimeout: *timeout,
+		})
+		defer client.Close()
+
+		var opts []limiter.Option
+		if *prefix != "" {
+			opts = append(opts, limiter.WithPrefix(*prefix))
+		}
+		rl, err := limiter.NewRedisLimiter(client, opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		l = rl
+		log.Printf("Using Redis at %s", *redisAddr)
+	} else {
+		l = limiter.NewMemoryLimiter()
+		log.Print("Using in-memory limiter")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, remote.NewHandler(l), *addr, *unixPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves h on the TCP address and Unix socket that are set until ctx is
+// done, then shuts down gracefully.
+func run(ctx context.Context, h http.Handler, addr, unixPath string) error {
+	var listeners []net.Listener
+	if addr != "" {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+	if unixPath != "" {
+		// A socket file left by an earlier run would make Listen fail.
+		if err := os.Remove(unixPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		ln, err := net.Listen("unix", unixPath)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		go func() {
+			log.Printf("Listening on %s %s", ln.Addr().Network(), ln.Addr())
+			if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-errs:
+		srv.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
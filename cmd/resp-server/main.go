@@ -0,0 +1,95 @@
+// Command resp-server answers THROTTLE commands over the Redis wire protocol,
+// so any Redis client can rate limit against it. See package resp for the
+// command.
+//
+// Usage:
+//
+//	resp-server -addr :6380 -redis localhost:6379
+//	redis-cli -p 6380 THROTTLE user:u42 20 10 1
+//
+// Without -redis the limiter is in memory, so the server itself holds the
+// buckets and no shared Redis is needed. The server finishes the commands in
+// flight before exiting on SIGINT or SIGTERM.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/resp"
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	addr := flag.String("addr", ":6380", "TCP address to listen on")
+	redisAddr := flag.String("redis", "", "Redis address; empty for an in-memory limiter")
+	prefix := flag.String("prefix", "", `prefix for Redis keys (default the library's "limiter:")`)
+	timeout := flag.Duration("timeout", 100*time.Millisecond, "timeout for each THROTTLE, and each Redis read and write")
+	flag.Parse()
+
+	var l limiter.RateLimiter
+	if *redisAddr != "" {
+		client := redis.NewClient(&redis.Options{
+			Addr:         *redisAddr,
+			ReadTimeout:  *timeout,
+			WriteTimeout: *timeout,
+		})
+		defer client.Close()
+
+		var opts []limiter.Option
+		if *prefix != "" {
+			opts = append(opts, limiter.WithPrefix(*prefix))
+		}
+		rl, err := limiter.NewRedisLimiter(client, opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		l = rl
+		log.Printf("Using Redis at %s", *redisAddr)
+	} else {
+		l = limiter.NewMemoryLimiter()
+		log.Print("Using in-memory limiter")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, resp.NewServer(l, resp.WithTimeout(*timeout)), *addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves srv on addr until ctx is done, then shuts it down gracefully.
+func run(ctx context.Context, srv *resp.Server, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", ln.Addr())
+		if err := srv.Serve(ln); !errors.Is(err, resp.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
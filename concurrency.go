@@ -0,0 +1,90 @@
+package limiter
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrConcurrencyLimitReached is returned by Acquire when the identity
+	// already holds the maximum number of leases.
+	ErrConcurrencyLimitReached = errors.New("limiter: concurrency limit reached")
+
+	// ErrLeaseExpired is returned by Renew when the lease has already been
+	// released or has expired and its slot may have been given to someone
+	// else.
+	ErrLeaseExpired = errors.New("limiter: lease expired")
+)
+
+// ConcurrencyLimiter caps how many requests an identity may have in flight at
+// once ("max 5 running exports per tenant"), which a token bucket cannot
+// express since it limits rate rather than duration.
+type ConcurrencyLimiter interface {
+	// Acquire takes one of max slots for the identity without blocking. It
+	// returns ErrConcurrencyLimitReached if all slots are taken. The caller
+	// must Release the lease when the work is done; leases that are neither
+	// released nor renewed expire, so a crashed caller cannot leak a slot.
+	Acquire(ctx context.Context, id Identity, max int64) (*Lease, error)
+
+	// InFlight returns the number of unexpired leases held by the identity.
+	InFlight(ctx context.Context, id Identity) (int64, error)
+}
+
+// Lease is a slot held in a ConcurrencyLimiter.
+type Lease struct {
+	id       Identity
+	released atomic.Bool
+	release  func(ctx context.Context) error
+	renew    func(ctx context.Context) error
+}
+
+// Identity returns the identity the lease was acquired for.
+func (l *Lease) Identity() Identity {
+	return l.id
+}
+
+// Release frees the slot. Releasing a lease more than once is a no-op.
+func (l *Lease) Release(ctx context.Context) error {
+	if !l.released.CompareAndSwap(false, true) {
+		return nil
+	}
+	return l.release(ctx)
+}
+
+// Renew pushes the lease's expiry out by the limiter's lease TTL, for work
+// that runs longer than the TTL. It returns ErrLeaseExpired if the lease was
+// released or has already expired.
+func (l *Lease) Renew(ctx context.Context) error {
+	if l.released.Load() {
+		return ErrLeaseExpired
+	}
+	return l.renew(ctx)
+}
+
+// checkMaxInFlight validates the max argument of Acquire.
+func checkMaxInFlight(max int64) error {
+	if max < 1 {
+		return fmt.Errorf("%w: max in-flight must be at least 1, got %d", ErrInvalidLimit, max)
+	}
+	return nil
+}
+
+// checkLeaseTTL validates the lease TTL of a ConcurrencyLimiter. Redis stores
+// expiries in milliseconds, so anything shorter would expire every lease at
+// once and the cap would never hold.
+func checkLeaseTTL(ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return fmt.Errorf("%w: lease ttl must be at least 1ms, got %v", ErrInvalidLimit, ttl)
+	}
+	return nil
+}
+
+// uniqueToken returns a random identifier that is unique across processes,
+// used to name leases and log entries.
+func uniqueToken() string {
+	return rand.Text()
+}
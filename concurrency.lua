@@ -0,0 +1,24 @@
+-- Leases are members of a sorted set scored by their expiry (milliseconds),
+-- so slots held by crashed callers are reclaimed once they expire.
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local max = tonumber(ARGV[3])
+local lease = ARGV[4]
+
+if not (max and max >= 1) then
+    return redis.error_reply('INVALIDLIMIT max in-flight must be at least 1')
+end
+
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+
+local count = redis.call('ZCARD', key)
+if count >= max then
+    return {0, count}
+end
+
+redis.call('ZADD', key, now + ttl, lease)
+redis.call('PEXPIRE', key, ttl)
+
+return {1, count + 1}
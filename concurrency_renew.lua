@@ -0,0 +1,19 @@
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local lease = ARGV[3]
+
+
+local expires = tonumber(redis.call('ZSCORE', key, lease))
+if expires == nil or expires <= now then
+    return 0
+end
+
+redis.call('ZADD', key, now + ttl, lease)
+
+-- The key must outlive its longest lease.
+if redis.call('PTTL', key) < ttl then
+    redis.call('PEXPIRE', key, ttl)
+end
+
+return 1
@@ -0,0 +1,121 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testConcurrencyLimiter(t *testing.T, l ConcurrencyLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+
+	t.Run("AcquireRelease", func(t *testing.T) {
+		id := newID("exports")
+
+		var leases []*Lease
+		for i := 0; i < 3; i++ {
+			lease, err := l.Acquire(ctx, id, 3)
+			if err != nil {
+				t.Fatalf("Acquire %d failed: %v", i, err)
+			}
+			leases = append(leases, lease)
+		}
+
+		if _, err := l.Acquire(ctx, id, 3); !errors.Is(err, ErrConcurrencyLimitReached) {
+			t.Fatalf("Expected ErrConcurrencyLimitReached, got %v", err)
+		}
+		if n, err := l.InFlight(ctx, id); err != nil || n != 3 {
+			t.Errorf("Expected 3 in flight, got %d (err %v)", n, err)
+		}
+
+		if err := leases[0].Release(ctx); err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+		// Releasing twice must not free a second slot.
+		if err := leases[0].Release(ctx); err != nil {
+			t.Fatalf("Second Release failed: %v", err)
+		}
+
+		if _, err := l.Acquire(ctx, id, 3); err != nil {
+			t.Fatalf("Expected a released slot to be reusable, got %v", err)
+		}
+		if _, err := l.Acquire(ctx, id, 3); !errors.Is(err, ErrConcurrencyLimitReached) {
+			t.Errorf("Expected only one slot to be freed, got %v", err)
+		}
+	})
+
+	t.Run("ExpiryAndRenew", func(t *testing.T) {
+		id := newID("crashy")
+
+		renewed, err := l.Acquire(ctx, id, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Acquire(ctx, id, 2); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		if err := renewed.Renew(ctx); err != nil {
+			t.Fatalf("Renew failed: %v", err)
+		}
+		time.Sleep(60 * time.Millisecond)
+
+		// The unrenewed lease has expired, freeing exactly one slot.
+		if n, err := l.InFlight(ctx, id); err != nil || n != 1 {
+			t.Errorf("Expected 1 in flight after expiry, got %d (err %v)", n, err)
+		}
+		if _, err := l.Acquire(ctx, id, 2); err != nil {
+			t.Errorf("Expected the expired slot to be reclaimed, got %v", err)
+		}
+
+		if err := renewed.Release(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := renewed.Renew(ctx); !errors.Is(err, ErrLeaseExpired) {
+			t.Errorf("Expected ErrLeaseExpired after release, got %v", err)
+		}
+	})
+}
+
+func TestMemoryConcurrencyLimiter(t *testing.T) {
+	limiter, err := NewMemoryConcurrencyLimiter(100 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testConcurrencyLimiter(t, limiter, func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestConcurrencyLimiter_InvalidTTL(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	for _, ttl := range []time.Duration{0, -time.Second, time.Microsecond} {
+		if _, err := NewMemoryConcurrencyLimiter(ttl); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Memory: expected ErrInvalidLimit for ttl %v, got %v", ttl, err)
+		}
+		if _, err := NewRedisConcurrencyLimiter(client, ttl); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Redis: expected ErrInvalidLimit for ttl %v, got %v", ttl, err)
+		}
+	}
+}
+
+func TestRedisConcurrencyLimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	mock := NewMockRecorder()
+	limiter, err := NewRedisConcurrencyLimiter(client, 100*time.Millisecond, WithRecorder(mock))
+	if err != nil {
+		t.Fatalf("Failed to create RedisConcurrencyLimiter: %v", err)
+	}
+	testConcurrencyLimiter(t, limiter, uniqueIdentity)
+
+	if mock.Counters["ratelimit.concurrency.acquire"] == 0 {
+		t.Error("Expected acquisitions to be recorded")
+	}
+	if mock.Counters["ratelimit.concurrency.release"] == 0 {
+		t.Error("Expected releases to be recorded")
+	}
+}
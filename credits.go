@@ -0,0 +1,31 @@
+package limiter
+
+import "context"
+
+// CreditLimiter enforces prepaid credit balances. Unlike a token bucket the
+// balance never refills on its own: credits are only added by TopUp.
+//
+// A denied Decision has a zero RetryAfter, since waiting does not help.
+type CreditLimiter interface {
+	// Allow deducts one credit. It is equivalent to AllowN with n == 1.
+	Allow(ctx context.Context, id Identity) (Decision, error)
+
+	// AllowN deducts n credits if the balance covers all of them.
+	AllowN(ctx context.Context, id Identity, n int64) (Decision, error)
+
+	// TopUp adds amount credits and returns the new balance.
+	TopUp(ctx context.Context, id Identity, amount int64) (int64, error)
+
+	// Balance returns the current balance without changing it. Identities
+	// that were never topped up have a balance of zero.
+	Balance(ctx context.Context, id Identity) (int64, error)
+}
+
+// creditDecision builds the Decision for a deduction that left balance
+// credits.
+func creditDecision(allowed bool, balance int64) Decision {
+	return Decision{
+		Allow:     allowed,
+		Remaining: balance,
+	}
+}
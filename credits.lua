@@ -0,0 +1,12 @@
+-- Prepaid credits: KEYS[1] holds an integer balance that only TopUp (INCRBY)
+-- increases. Deducts the cost only if the balance covers it.
+local key = KEYS[1]
+local cost = tonumber(ARGV[1])
+
+local balance = tonumber(redis.call('GET', key) or '0')
+
+if balance < cost then
+    return {0, balance}
+end
+
+return {1, redis.call('DECRBY', key, cost)}
@@ -0,0 +1,160 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testCredits(t *testing.T, l CreditLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+
+	t.Run("DeductAndTopUp", func(t *testing.T) {
+		id := newID("prepaid")
+
+		if dec, err := l.Allow(ctx, id); err != nil || dec.Allow {
+			t.Fatalf("Expected a new identity to have no credits, got %+v (err %v)", dec, err)
+		}
+
+		if balance, err := l.TopUp(ctx, id, 10); err != nil || balance != 10 {
+			t.Fatalf("Expected balance 10 after top-up, got %d (err %v)", balance, err)
+		}
+
+		if dec, err := l.AllowN(ctx, id, 7); err != nil || !dec.Allow || dec.Remaining != 3 {
+			t.Fatalf("Expected 7 credits deducted with 3 remaining, got %+v (err %v)", dec, err)
+		}
+
+		// All or nothing: a request for more than the balance takes nothing.
+		dec, err := l.AllowN(ctx, id, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow || dec.Remaining != 3 || dec.RetryAfter != 0 {
+			t.Errorf("Expected denial with 3 remaining and no RetryAfter, got %+v", dec)
+		}
+
+		if balance, err := l.Balance(ctx, id); err != nil || balance != 3 {
+			t.Errorf("Expected balance 3, got %d (err %v)", balance, err)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		id := newID("race")
+		if _, err := l.TopUp(ctx, id, 50); err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			allowed int
+		)
+		for range 100 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if dec, err := l.Allow(ctx, id); err == nil && dec.Allow {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed != 50 {
+			t.Errorf("Expected exactly 50 deductions, got %d", allowed)
+		}
+		if balance, _ := l.Balance(ctx, id); balance != 0 {
+			t.Errorf("Expected an empty balance, got %d", balance)
+		}
+	})
+
+	t.Run("InvalidAmount", func(t *testing.T) {
+		id := newID("invalid")
+		if _, err := l.AllowN(ctx, id, 0); !errors.Is(err, ErrInvalidCost) {
+			t.Errorf("Expected ErrInvalidCost for AllowN, got %v", err)
+		}
+		if _, err := l.TopUp(ctx, id, -5); !errors.Is(err, ErrInvalidCost) {
+			t.Errorf("Expected ErrInvalidCost for TopUp, got %v", err)
+		}
+	})
+}
+
+func TestMemoryCreditLimiter(t *testing.T) {
+	testCredits(t, NewMemoryCreditLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisCreditLimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisCreditLimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisCreditLimiter: %v", err)
+	}
+	testCredits(t, limiter, uniqueIdentity)
+}
+
+func TestRedisCreditLimiter_ReservedNamespace(t *testing.T) {
+	l, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisCreditLimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisCreditLimiter: %v", err)
+	}
+
+	ctx := context.Background()
+	id := uniqueIdentity("reserved")
+	if _, err := limiter.TopUp(ctx, id, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token bucket of {credits:ns, k} would share a key with the balance
+	// of {ns, k}.
+	alias := Identity{Namespace: "credits:" + id.Namespace, Key: id.Key}
+	if err := l.Reset(ctx, alias); !errors.Is(err, ErrReservedNamespace) {
+		t.Errorf("Expected ErrReservedNamespace from Reset, got %v", err)
+	}
+	if _, err := l.Allow(ctx, alias, Limit{Rate: 1, Period: time.Second, Burst: 1}); !errors.Is(err, ErrReservedNamespace) {
+		t.Errorf("Expected ErrReservedNamespace from Allow, got %v", err)
+	}
+
+	if balance, err := limiter.Balance(ctx, id); err != nil || balance != 5 {
+		t.Errorf("Expected the balance of 5 to survive, got %d (err %v)", balance, err)
+	}
+}
+
+func TestRedisCreditLimiter_Metrics(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	mock := NewMockRecorder()
+	limiter, err := NewRedisCreditLimiter(client, WithRecorder(mock))
+	if err != nil {
+		t.Fatalf("Failed to create RedisCreditLimiter: %v", err)
+	}
+
+	ctx := context.Background()
+	id := uniqueIdentity("metrics")
+
+	if _, err := limiter.TopUp(ctx, id, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.AllowN(ctx, id, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.AllowN(ctx, id, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mock.Counters["ratelimit.credits.topup"]; got != 5 {
+		t.Errorf("Expected 5 credits topped up, got %v", got)
+	}
+	// The denied deduction is not counted as consumption.
+	if got := mock.Counters["ratelimit.credits.consumed"]; got != 3 {
+		t.Errorf("Expected 3 credits consumed, got %v", got)
+	}
+}
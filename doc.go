@@ -15,7 +15,8 @@
 //
 //   - Each identity has a "bucket" holding tokens.
 //   - The bucket refills over time up to a maximum capacity (Burst).
-//   - Each Allow call consumes 1 token when available.
+//   - Each Allow call consumes 1 token when available; AllowN consumes n
+//     tokens at once for requests that are more expensive than others.
 //
 // Unlike fixed-window counters, token buckets naturally support bursts while
 // still enforcing a long-term average rate.
@@ -52,6 +53,24 @@
 // Recommendation: use RedisLimiter in production when you need a global limit,
 // and MemoryLimiter in tests (as a fast, dependency-free stand-in).
 //
+// # Other Algorithms
+//
+// Other algorithms implement the same RateLimiter interface, so they can be
+// swapped in for a token bucket:
+//
+//   - MemorySlidingLogLimiter and RedisSlidingLogLimiter record every admitted
+//     event and allow at most Limit.Rate events in any rolling Limit.Period.
+//     They are exact, at the cost of memory proportional to Rate.
+//   - MemoryFixedWindowLimiter and RedisFixedWindowLimiter count events in
+//     epoch-aligned windows of Limit.Period. They need one counter per
+//     identity but allow up to twice Rate across a window boundary.
+//   - MemorySlidingWindowLimiter and RedisSlidingWindowLimiter weight the
+//     previous window's count by its overlap with the rolling window. They
+//     need two counters per identity and are approximate.
+//   - MemoryGCRALimiter and RedisGCRALimiter implement the generic cell rate
+//     algorithm. They admit the same traffic as a token bucket but store a
+//     single theoretical arrival time per identity.
+//
 // # Concurrency
 //
 // MemoryLimiter is safe for concurrent use by multiple goroutines (it uses a
@@ -69,6 +88,12 @@
 // caller decides whether to deny traffic (protect the backend) or allow traffic
 // (maximize availability).
 //
+// Errors are classified by sentinels usable with errors.Is: ErrInvalidLimit
+// and ErrInvalidCost for unusable arguments, ErrBackendUnavailable for Redis
+// failures (wrapping the original error, so context errors still match) and
+// ErrScriptResponse for unexpected Lua replies. Call Limit.Validate to check
+// a Limit up front.
+//
 // # Decision Semantics
 //
 // Decision fields are intended to be directly consumable by application code:
@@ -77,9 +102,89 @@
 //   - Remaining is the number of whole tokens remaining after the decision is
 //     applied (floored to an int64).
 //   - RetryAfter is 0 when allowed; when denied it is the approximate duration
-//     until a single token is expected to be available.
+//     until enough tokens to cover the request's cost are expected to be
+//     available.
 //   - ResetTime is the absolute timestamp corresponding to time.Now()+RetryAfter.
 //
+// The headers subpackage renders a Decision as Retry-After plus the IETF
+// RateLimit or legacy X-RateLimit-* response headers, and the middleware
+// subpackage rate limits net/http handlers with any RateLimiter. The clientip
+// subpackage derives a client IP identity from trusted proxy headers.
+// The remote subpackage serves any RateLimiter over HTTP/JSON and provides a
+// Client that implements RateLimiter against it, and the resp subpackage
+// answers a THROTTLE command over the Redis wire protocol.
+//
+// # Waiting Instead of Rejecting
+//
+// Both backends implement Reserver. WaitN blocks until the tokens are
+// available, and fails fast with ErrWaitExceedsDeadline when the context
+// deadline would pass first. ReserveN deducts tokens immediately, letting the
+// bucket go into debt, and returns a Reservation with the time the caller may
+// act; cancelling it returns the tokens.
+//
+// Both backends also implement Peeker, which reports an identity's refilled
+// balance (BucketState) without consuming tokens or writing any state.
+//
+// For requests whose cost is only known afterwards, Authorizer deducts an
+// estimate up front and returns an Authorization. Settling it with the actual
+// cost refunds or charges the difference, optionally letting the bucket go
+// into bounded debt.
+//
+// # Composite Limits
+//
+// Both backends implement MultiLimiter. AllowAll evaluates several Checks
+// (different limits for one identity via PerLimit, or several identities such
+// as a user and its tenant) and deducts tokens only if every bucket admits the
+// request. The MultiDecision reports which check was binding and the longest
+// RetryAfter. RedisLimiter evaluates all checks in a single Lua script, so
+// partial consumption never happens.
+//
+// Both backends also implement HierarchyLimiter, which evaluates nested
+// levels (for example global, tenant, user) in the same atomic way. With
+// Hierarchy.Borrow set, a level that has run out may take its shortfall from
+// its parent's spare capacity.
+//
+// # Batches
+//
+// Both backends implement BatchLimiter. AllowMany evaluates many independent
+// checks in one call: RedisLimiter pipelines them into a single round trip and
+// MemoryLimiter takes its lock once. Each check gets its own Result.
+//
+// # Concurrency Limits
+//
+// ConcurrencyLimiter caps how many requests an identity may have in flight,
+// rather than how often it may call. Acquire returns a Lease that must be
+// released when the work is done. MemoryConcurrencyLimiter keeps leases in
+// process; RedisConcurrencyLimiter keeps them in a sorted set scored by
+// expiry, so slots held by crashed instances are reclaimed after the lease
+// TTL.
+//
+// # Policy Resolution
+//
+// A PolicyResolver maps an Identity to its Limit, for example by plan tier.
+// PolicyLimiter wraps any RateLimiter with a resolver so handlers can call
+// Allow(ctx, id) directly. StaticResolver, NamespaceResolver, TierResolver,
+// ResolverChain and CachingResolver cover the common cases.
+// A resolver that is also a CostResolver, such as policy.Store, sets what
+// one request costs as well.
+//
+// MemoryLimiter and RedisLimiter also implement OverrideStore, which replaces
+// one identity's Limit in every token bucket operation, optionally for a
+// limited time, without changing what callers pass.
+//
+// # Quotas
+//
+// QuotaLimiter enforces allowances that reset on calendar boundaries, such as
+// "1,000 calls per day" or "50,000 per month", in a configurable time zone.
+// Decisions report the end of the current interval as ResetTime.
+// MemoryQuotaLimiter and RedisQuotaLimiter implement it.
+//
+// # Credits
+//
+// CreditLimiter enforces prepaid balances that only grow through TopUp.
+// MemoryCreditLimiter and RedisCreditLimiter implement it; the latter reports
+// consumption and top-ups through the MetricsRecorder.
+//
 // # Usage
 //
 // For a runnable example using MemoryLimiter, see ExampleMemoryLimiter in
@@ -107,14 +212,17 @@
 //     store with TTL/LRU eviction.
 //   - RedisLimiter requires a reachable Redis instance and returns errors
 //     directly; callers must decide their availability vs protection tradeoff.
-//   - This package currently models each Allow call as a cost of 1 token.
+//   - Allow always costs 1 token. Use AllowN for weighted costs; a cost larger
+//     than Burst, or than the burst of the identity's override, can never be
+//     satisfied and returns ErrCostExceedsBurst.
 //   - RedisLimiter uses EVALSHA; if Redis is restarted and script cache is
 //     cleared, Allow may return a NOSCRIPT error until the script is reloaded
 //     (recreating the limiter via NewRedisLimiter will load it).
 //
 // # Configuration
 //
-// RedisLimiter is configured using the Functional Options pattern:
+// RedisLimiter and the other Redis-backed limiters are configured using the
+// Functional Options pattern:
 //
 //	limiter, _ := NewRedisLimiter(client,
 //		WithPrefix("myapp:rate:"),
@@ -128,4 +236,7 @@
 //   - WithTimeout(time.Duration): Sets the context timeout for Redis operations
 //     (default 5s).
 //   - WithRecorder(MetricsRecorder): Injects a custom metrics backend.
+//
+// Limits themselves can be declared in a JSON file and hot-reloaded with the
+// policy subpackage.
 package limiter
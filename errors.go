@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidLimit is returned when a Limit (or Quota) cannot be enforced,
+	// for example because its Rate or Period is not positive. See
+	// Limit.Validate.
+	ErrInvalidLimit = errors.New("limiter: invalid limit")
+
+	// ErrBackendUnavailable wraps errors from the backing store, such as a
+	// Redis connection failure or timeout. The original error is wrapped too,
+	// so errors.Is(err, context.DeadlineExceeded) still works.
+	ErrBackendUnavailable = errors.New("limiter: backend unavailable")
+
+	// ErrScriptResponse is returned when a Lua script replies with something
+	// the limiter does not understand, which usually means a different version
+	// of the script is loaded in Redis.
+	ErrScriptResponse = errors.New("limiter: unexpected script response")
+
+	// ErrNoPolicy is returned by a PolicyResolver that has no limit for an
+	// identity.
+	ErrNoPolicy = errors.New("limiter: no policy")
+
+	// ErrInvalidCost is returned when a caller asks for fewer than one token.
+	ErrInvalidCost = errors.New("limiter: cost must be at least 1")
+
+	// ErrCostExceedsBurst is returned when a single request asks for more
+	// tokens than the bucket can ever hold (or, for window-based limiters,
+	// more events than fit in one window). Such a request would be denied
+	// forever, so it is rejected up front instead of returning a RetryAfter.
+	ErrCostExceedsBurst = errors.New("limiter: cost exceeds burst")
+
+	// ErrWaitExceedsDeadline is returned by WaitN when the tokens would not be
+	// available before the context deadline. No tokens are consumed.
+	ErrWaitExceedsDeadline = errors.New("limiter: wait would exceed context deadline")
+
+	// ErrReservedNamespace is returned by RedisLimiter for identities whose
+	// namespace starts with the kind of another limiter's keys, such as
+	// "override" or "credits", so that their bucket keys would alias that
+	// state.
+	ErrReservedNamespace = errors.New("limiter: reserved namespace")
+)
+
+// checkAmount validates an amount of n tokens or credits to add or take,
+// which must be at least one.
+func checkAmount(n int64) error {
+	if n < 1 {
+		return fmt.Errorf("%w: got %d", ErrInvalidCost, n)
+	}
+	return nil
+}
+
+// checkRefund validates a refund of n tokens to a bucket governed by limit.
+func checkRefund(limit Limit, n int64) error {
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	return checkAmount(n)
+}
+
+// checkCost validates a request for n tokens against a token bucket, whose
+// capacity is limit.Burst.
+func checkCost(limit Limit, n int64) error {
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	return checkCapacity(n, limit.Burst)
+}
+
+// checkWindowCost validates a request for n events against a window-based
+// limiter, whose capacity is limit.Rate events per window.
+func checkWindowCost(limit Limit, n int64) error {
+	if err := limit.validateWindow(); err != nil {
+		return err
+	}
+	return checkCapacity(n, limit.Rate)
+}
+
+// checkQuotaCost validates a request for n events against a quota.
+func checkQuotaCost(quota Quota, n int64) error {
+	if err := quota.Validate(); err != nil {
+		return err
+	}
+	return checkCapacity(n, quota.Max)
+}
+
+// scriptResponseError builds the error returned when a Lua script's reply
+// cannot be parsed.
+func scriptResponseError(result interface{}) error {
+	return fmt.Errorf("%w: %#v", ErrScriptResponse, result)
+}
+
+func checkCapacity(n, capacity int64) error {
+	if err := checkAmount(n); err != nil {
+		return err
+	}
+	if n > capacity {
+		return fmt.Errorf("%w: cost %d, capacity %d", ErrCostExceedsBurst, n, capacity)
+	}
+	return nil
+}
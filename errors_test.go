@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLimit_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit Limit
+		valid bool
+	}{
+		{"Valid", Limit{Rate: 10, Period: time.Second, Burst: 5}, true},
+		{"ZeroRate", Limit{Rate: 0, Period: time.Second, Burst: 5}, false},
+		{"NegativeRate", Limit{Rate: -1, Period: time.Second, Burst: 5}, false},
+		{"ZeroPeriod", Limit{Rate: 10, Burst: 5}, false},
+		{"ZeroBurst", Limit{Rate: 10, Period: time.Second}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limit.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid limit, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidLimit) {
+				t.Errorf("Expected ErrInvalidLimit, got %v", err)
+			}
+		})
+	}
+}
+
+func testInvalidLimit(t *testing.T, l RateLimiter, id Identity) {
+	ctx := context.Background()
+
+	for _, limit := range []Limit{
+		{Rate: 0, Period: time.Second, Burst: 5},
+		{Rate: 10, Period: 0, Burst: 5},
+	} {
+		if _, err := l.Allow(ctx, id, limit); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Expected ErrInvalidLimit for %+v, got %v", limit, err)
+		}
+	}
+}
+
+func TestInvalidLimit(t *testing.T) {
+	id := Identity{Namespace: "test", Key: "invalid"}
+
+	for name, l := range map[string]RateLimiter{
+		"MemoryLimiter":              NewMemoryLimiter(),
+		"MemoryGCRALimiter":          NewMemoryGCRALimiter(),
+		"MemorySlidingLogLimiter":    NewMemorySlidingLogLimiter(),
+		"MemoryFixedWindowLimiter":   NewMemoryFixedWindowLimiter(),
+		"MemorySlidingWindowLimiter": NewMemorySlidingWindowLimiter(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			testInvalidLimit(t, l, id)
+		})
+	}
+
+	t.Run("RedisLimiter", func(t *testing.T) {
+		limiter, _ := newIntegrationLimiter(t)
+		testInvalidLimit(t, limiter, uniqueIdentity("invalid"))
+	})
+}
+
+func TestRedisLimiter_ScriptValidation(t *testing.T) {
+	limiter, client := newIntegrationLimiter(t)
+	ctx := context.Background()
+
+	// Bypass the Go-side validation to reach the script's own checks.
+	err := tokenBucketScript.EvalSha(ctx, client, []string{limiter.key(uniqueIdentity("lua"))},
+		0,   // rate
+		5,   // capacity
+		1.0, // now
+		1,   // cost
+	).Err()
+	if err == nil {
+		t.Fatal("Expected the script to reject a zero rate")
+	}
+	if err := backendError(err); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
+
+func TestRedisLimiter_BackendUnavailable(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1})
+	defer client.Close()
+
+	_, err := NewRedisLimiter(client, WithTimeout(100*time.Millisecond))
+	if !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("Expected ErrBackendUnavailable, got %v", err)
+	}
+}
+
+func TestParseDecision_ScriptResponse(t *testing.T) {
+	if _, err := parseDecision("not a decision"); !errors.Is(err, ErrScriptResponse) {
+		t.Errorf("Expected ErrScriptResponse, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+-- Fixed window counter: KEYS[1] counts the events admitted in the current
+-- window and expires when the window ends. Times are in microseconds.
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window_left = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+if not (window_left and window_left >= 0 and limit and limit > 0) then
+    return redis.error_reply('INVALIDLIMIT window and limit must be positive')
+end
+
+local count = tonumber(redis.call('GET', key) or '0')
+
+if count + cost <= limit then
+    redis.call('INCRBY', key, cost)
+    redis.call('PEXPIRE', key, math.max(1, math.ceil(window_left / 1000)))
+
+    return {1, limit - count - cost, '0', tostring(now / 1e6)}
+end
+
+local retry_after = window_left / 1e6
+
+return {0, limit - count, tostring(retry_after), tostring(now / 1e6 + retry_after)}
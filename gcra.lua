@@ -0,0 +1,36 @@
+-- Generic cell rate algorithm: KEYS[1] holds the theoretical arrival time
+-- (TAT) in integer microseconds. Mirrors gcra in memory_gcra.go.
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+if not (interval and interval > 0 and burst and burst > 0) then
+    return redis.error_reply('INVALIDLIMIT interval and burst must be positive')
+end
+
+local tolerance = interval * burst
+
+local tat = tonumber(redis.call('GET', key) or '0')
+if tat < now then
+    tat = now
+end
+
+local next_tat = tat + interval * cost
+local allow_at = next_tat - tolerance
+
+if allow_at > now then
+    local retry_after = (allow_at - now) / 1e6
+    local remaining = math.floor((now + tolerance - tat) / interval)
+
+    return {0, remaining, tostring(retry_after), tostring(now / 1e6 + retry_after)}
+end
+
+-- The key is useless once the TAT has passed, so it expires then. The value
+-- is formatted explicitly because tostring would round it.
+redis.call('SET', key, string.format('%d', next_tat), 'PX', math.max(1, math.ceil((next_tat - now) / 1000)))
+
+local remaining = math.floor((now + tolerance - next_tat) / interval)
+
+return {1, remaining, '0', tostring(now / 1e6)}
@@ -0,0 +1,100 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testGCRA(t *testing.T, l RateLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 5}
+
+	t.Run("MatchesTokenBucket", func(t *testing.T) {
+		id := newID("gcra")
+		bucket := NewMemoryLimiter()
+
+		// Same sequence as a token bucket: a burst of 5, then denial.
+		for i, n := range []int64{2, 1, 2, 1} {
+			got, err := l.AllowN(ctx, id, limit, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := bucket.AllowN(ctx, id, limit, n)
+			if got.Allow != want.Allow || got.Remaining != want.Remaining {
+				t.Errorf("Call %d: expected Allow=%v Remaining=%d, got Allow=%v Remaining=%d",
+					i, want.Allow, want.Remaining, got.Allow, got.Remaining)
+			}
+		}
+	})
+
+	t.Run("RetryAfter", func(t *testing.T) {
+		id := newID("retry")
+
+		if dec, err := l.AllowN(ctx, id, limit, 5); err != nil || !dec.Allow || dec.Remaining != 0 {
+			t.Fatalf("Expected the burst to be allowed, got %+v (err %v)", dec, err)
+		}
+
+		dec, err := l.AllowN(ctx, id, limit, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Fatal("Expected denial with an empty bucket")
+		}
+		// Two tokens at 10/s take 200ms.
+		if dec.RetryAfter < 190*time.Millisecond || dec.RetryAfter > 200*time.Millisecond {
+			t.Errorf("Expected RetryAfter ~200ms, got %v", dec.RetryAfter)
+		}
+		if !dec.ResetTime.After(time.Now()) {
+			t.Errorf("Expected ResetTime in the future, got %v", dec.ResetTime)
+		}
+
+		time.Sleep(dec.RetryAfter)
+		if dec, err := l.AllowN(ctx, id, limit, 2); err != nil || !dec.Allow {
+			t.Errorf("Expected 2 tokens after waiting, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("CostExceedsBurst", func(t *testing.T) {
+		if _, err := l.AllowN(ctx, newID("bulk"), limit, 6); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+		}
+	})
+}
+
+func TestMemoryGCRALimiter(t *testing.T) {
+	testGCRA(t, NewMemoryGCRALimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisGCRALimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisGCRALimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisGCRALimiter: %v", err)
+	}
+	testGCRA(t, limiter, uniqueIdentity)
+
+	t.Run("SingleKeyWithExpiry", func(t *testing.T) {
+		ctx := context.Background()
+		id := uniqueIdentity("ttl")
+		limit := Limit{Rate: 10, Period: time.Second, Burst: 5}
+
+		if _, err := limiter.AllowN(ctx, id, limit, 3); err != nil {
+			t.Fatal(err)
+		}
+
+		key := limiter.keyFor(gcraKind, id)
+		if kind := client.Type(ctx, key).Val(); kind != "string" {
+			t.Errorf("Expected a plain string key, got %q", kind)
+		}
+		// The TAT is 300ms ahead of now.
+		if ttl := client.PTTL(ctx, key).Val(); ttl <= 0 || ttl > 300*time.Millisecond {
+			t.Errorf("Expected PX expiry of ~300ms, got %v", ttl)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+// Package headers renders a limiter.Decision as HTTP rate-limit response
+// headers.
+//
+// Retry-After is always written on a denial, as whole seconds rounded up (RFC
+// 9110 allows only an integer or an HTTP-date). The remaining headers depend
+// on the Style chosen for the deployment:
+//
+//   - IETF: RateLimit-Policy and RateLimit, from the IETF httpapi draft
+//     "RateLimit header fields for HTTP".
+//   - Legacy: X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+//     (the Unix time the bucket is full again), as sent by many existing APIs.
+//
+// A Writer renders one of them, both, or neither:
+//
+//	w := headers.Writer{Style: headers.IETF | headers.Legacy}
+//	w.Write(rw.Header(), dec, limit)
+package headers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// Style selects which header sets a Writer emits in addition to Retry-After.
+// Styles combine with |; the zero Style writes Retry-After only.
+type Style uint8
+
+const (
+	// IETF emits RateLimit-Policy and RateLimit.
+	IETF Style = 1 << iota
+	// Legacy emits X-RateLimit-Limit, X-RateLimit-Remaining and
+	// X-RateLimit-Reset.
+	Legacy
+)
+
+// ParseStyle parses a comma-separated list of "ietf" and "legacy", or "none",
+// as used in configuration files and flags.
+func ParseStyle(s string) (Style, error) {
+	var style Style
+	for _, name := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "ietf":
+			style |= IETF
+		case "legacy":
+			style |= Legacy
+		case "none", "":
+		default:
+			return 0, fmt.Errorf("headers: unknown style %q", name)
+		}
+	}
+	return style, nil
+}
+
+// String returns the style in the form accepted by ParseStyle.
+func (s Style) String() string {
+	switch s {
+	case 0:
+		return "none"
+	case IETF:
+		return "ietf"
+	case Legacy:
+		return "legacy"
+	case IETF | Legacy:
+		return "ietf,legacy"
+	default:
+		return fmt.Sprintf("Style(%d)", uint8(s))
+	}
+}
+
+// DefaultPolicy is the policy name used in the IETF headers when
+// Writer.Policy is empty.
+const DefaultPolicy = "default"
+
+// Writer writes rate-limit headers for a Decision. The zero Writer writes
+// Retry-After only.
+type Writer struct {
+	Style Style
+
+	// Policy names the policy in RateLimit-Policy and RateLimit. It must be
+	// a valid structured-field token, for example "api" or "per-user".
+	Policy string
+}
+
+// Write sets the headers describing dec, made under limit, on h. Headers not
+// selected by the style are left untouched.
+func (w Writer) Write(h http.Header, dec limiter.Decision, limit limiter.Limit) {
+	w.write(h, dec, limit, time.Now())
+}
+
+func (w Writer) write(h http.Header, dec limiter.Decision, limit limiter.Limit, now time.Time) {
+	if !dec.Allow {
+		h.Set("Retry-After", RetryAfter(dec.RetryAfter))
+	}
+
+	reset := seconds(resetAfter(dec, limit))
+	if w.Style&IETF != 0 {
+		policy := w.Policy
+		if policy == "" {
+			policy = DefaultPolicy
+		}
+		// The quota is the burst, like X-RateLimit-Limit, so r never exceeds
+		// q; the window is the time an empty bucket takes to refill it.
+		window := time.Duration(float64(limit.Burst) / float64(limit.Rate) * float64(limit.Period))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%s;q=%d;w=%d", policy, limit.Burst, seconds(window)))
+		h.Set("RateLimit", fmt.Sprintf("%s;r=%d;t=%d", policy, dec.Remaining, reset))
+	}
+	if w.Style&Legacy != 0 {
+		h.Set("X-RateLimit-Limit", strconv.FormatInt(limit.Burst, 10))
+		h.Set("X-RateLimit-Remaining", strconv.FormatInt(dec.Remaining, 10))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(now.Unix()+reset, 10))
+	}
+}
+
+// resetAfter approximates the time until the bucket is full again from the
+// whole tokens remaining. Decision.ResetTime only tells when the request
+// would be admitted, which is now for an allowed one. Remaining is floored, so
+// the result may be up to one token's interval late, never early.
+func resetAfter(dec limiter.Decision, limit limiter.Limit) time.Duration {
+	missing := limit.Burst - dec.Remaining
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(float64(missing) / float64(limit.Rate) * float64(limit.Period))
+}
+
+// RetryAfter formats d as a Retry-After value: whole seconds, rounded up so
+// clients never retry early.
+func RetryAfter(d time.Duration) string {
+	return strconv.FormatInt(seconds(d), 10)
+}
+
+// seconds rounds d up to whole seconds, treating negative durations as 0.
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,144 @@
+package headers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0"},
+		{-time.Second, "0"},
+		{time.Millisecond, "1"},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{time.Minute, "60"},
+	}
+	for _, tt := range tests {
+		if got := RetryAfter(tt.d); got != tt.want {
+			t.Errorf("RetryAfter(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limit := limiter.Limit{Rate: 100, Period: time.Minute, Burst: 150}
+	denied := limiter.Decision{
+		Allow:      false,
+		Remaining:  0,
+		RetryAfter: 2300 * time.Millisecond,
+		ResetTime:  now.Add(2300 * time.Millisecond),
+	}
+	allowed := limiter.Decision{Allow: true, Remaining: 42, ResetTime: now}
+
+	tests := []struct {
+		name   string
+		writer Writer
+		dec    limiter.Decision
+		want   map[string]string
+	}{
+		{
+			name:   "RetryAfterOnly",
+			writer: Writer{},
+			dec:    denied,
+			want:   map[string]string{"Retry-After": "3"},
+		},
+		{
+			name:   "AllowedWritesNoRetryAfter",
+			writer: Writer{},
+			dec:    allowed,
+			want:   map[string]string{},
+		},
+		{
+			name:   "IETF",
+			writer: Writer{Style: IETF, Policy: "api"},
+			dec:    denied,
+			want: map[string]string{
+				"Retry-After":      "3",
+				"RateLimit-Policy": "api;q=150;w=90",
+				"RateLimit":        "api;r=0;t=90",
+			},
+		},
+		{
+			name:   "IETFDefaultPolicy",
+			writer: Writer{Style: IETF},
+			dec:    allowed,
+			want: map[string]string{
+				"RateLimit-Policy": "default;q=150;w=90",
+				"RateLimit":        "default;r=42;t=65",
+			},
+		},
+		{
+			name:   "Legacy",
+			writer: Writer{Style: Legacy},
+			dec:    denied,
+			want: map[string]string{
+				"Retry-After":           "3",
+				"X-RateLimit-Limit":     "150",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "1700000090",
+			},
+		},
+		{
+			name:   "Both",
+			writer: Writer{Style: IETF | Legacy},
+			dec:    allowed,
+			want: map[string]string{
+				"RateLimit-Policy":      "default;q=150;w=90",
+				"RateLimit":             "default;r=42;t=65",
+				"X-RateLimit-Limit":     "150",
+				"X-RateLimit-Remaining": "42",
+				"X-RateLimit-Reset":     "1700000065",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			tt.writer.write(h, tt.dec, limit, now)
+
+			if len(h) != len(tt.want) {
+				t.Errorf("Expected %d headers, got %v", len(tt.want), h)
+			}
+			for name, want := range tt.want {
+				if got := h.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	tests := map[string]Style{
+		"":             0,
+		"none":         0,
+		"ietf":         IETF,
+		"Legacy":       Legacy,
+		"ietf,legacy":  IETF | Legacy,
+		"legacy, ietf": IETF | Legacy,
+	}
+	for in, want := range tests {
+		got, err := ParseStyle(in)
+		if err != nil || got != want {
+			t.Errorf("ParseStyle(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseStyle("draft"); err == nil {
+		t.Error("Expected an error for an unknown style")
+	}
+	for _, s := range []Style{0, IETF, Legacy, IETF | Legacy} {
+		if got, _ := ParseStyle(s.String()); got != s {
+			t.Errorf("ParseStyle(%q) = %v, want %v", s.String(), got, s)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package limiter
+
+import "context"
+
+// Hierarchy describes nested limits that a request must fit within at every
+// level, such as a global budget shared by tenants that is in turn shared by
+// their users. Levels are ordered from the outermost to the innermost:
+//
+//	h := Hierarchy{
+//		Levels: []Check{
+//			{ID: Identity{Namespace: "global", Key: "all"}, Limit: globalLimit},
+//			{ID: Identity{Namespace: "tenant", Key: tenantID}, Limit: tenantLimit},
+//			{ID: Identity{Namespace: "user", Key: userID}, Limit: userLimit},
+//		},
+//		Borrow: true,
+//	}
+type Hierarchy struct {
+	Levels []Check
+
+	// Borrow lets a level that cannot cover its cost spend what it has and
+	// take the shortfall from its parent's spare capacity, on top of what the
+	// parent pays for itself. The outermost level cannot borrow. Without
+	// Borrow, a Hierarchy behaves exactly like AllowAll over its levels.
+	Borrow bool
+}
+
+// HierarchyLimiter is implemented by limiters that can evaluate a whole
+// Hierarchy atomically: every level is charged, or none is.
+type HierarchyLimiter interface {
+	AllowHierarchy(ctx context.Context, h Hierarchy) (MultiDecision, error)
+}
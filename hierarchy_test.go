@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testHierarchyLimiter(t *testing.T, l interface {
+	HierarchyLimiter
+	Peeker
+}, newID func(string) Identity) {
+	ctx := context.Background()
+	perMinute := func(burst int64) Limit {
+		return Limit{Rate: 1, Period: time.Minute, Burst: burst}
+	}
+	newHierarchy := func(borrow bool) Hierarchy {
+		return Hierarchy{
+			Levels: []Check{
+				{ID: newID("global"), Limit: perMinute(5)},
+				{ID: newID("tenant"), Limit: perMinute(6)},
+				{ID: newID("user"), Limit: perMinute(2)},
+			},
+			Borrow: borrow,
+		}
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		h := newHierarchy(false)
+		for i := 0; i < 2; i++ {
+			if md, err := l.AllowHierarchy(ctx, h); err != nil || !md.Allow {
+				t.Fatalf("Request %d: expected allow, got %+v (err %v)", i, md, err)
+			}
+		}
+
+		md, err := l.AllowHierarchy(ctx, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Allow || md.Binding != 2 {
+			t.Errorf("Expected the user level to deny the 3rd request, got %+v", md)
+		}
+	})
+
+	t.Run("Borrow", func(t *testing.T) {
+		h := newHierarchy(true)
+		for i := 0; i < 4; i++ {
+			if md, err := l.AllowHierarchy(ctx, h); err != nil || !md.Allow {
+				t.Fatalf("Request %d: expected allow, got %+v (err %v)", i, md, err)
+			}
+		}
+
+		// Requests 3 and 4 were borrowed: the tenant paid for itself and for
+		// the user, draining all 6 of its tokens.
+		st, err := l.Peek(ctx, h.Levels[1].ID, h.Levels[1].Limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Remaining != 0 {
+			t.Errorf("Expected the tenant to be drained by borrowing, got %d remaining", st.Remaining)
+		}
+
+		// The 5th request needs 3 tokens from the global level (1 of its own
+		// plus 2 borrowed through the tenant) but only 1 is left.
+		md, err := l.AllowHierarchy(ctx, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Allow || md.Binding != 0 {
+			t.Fatalf("Expected the global level to deny the 5th request, got %+v", md)
+		}
+		if md.RetryAfter < 119*time.Second || md.RetryAfter > 2*time.Minute {
+			t.Errorf("Expected RetryAfter of ~2m for 2 missing global tokens, got %v", md.RetryAfter)
+		}
+
+		// Nothing may be deducted when the chain is denied.
+		st, err = l.Peek(ctx, h.Levels[0].ID, h.Levels[0].Limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Remaining != 1 {
+			t.Errorf("Expected the global level to keep its last token, got %d", st.Remaining)
+		}
+	})
+}
+
+func TestMemoryLimiter_AllowHierarchy(t *testing.T) {
+	n := 0
+	testHierarchyLimiter(t, NewMemoryLimiter(), func(key string) Identity {
+		n++
+		return Identity{Namespace: "test", Key: fmt.Sprintf("%s_%d", key, n)}
+	})
+}
+
+func TestRedisLimiter_AllowHierarchy(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testHierarchyLimiter(t, limiter, uniqueIdentity)
+}
@@ -17,71 +17,334 @@ type state struct {
 // to the process and is not shared across replicas. Use RedisLimiter when you
 // need a single global limit across multiple instances.
 type MemoryLimiter struct {
-	mu      sync.Mutex
-	buckets map[string]*state
+	mu        sync.Mutex
+	buckets   map[string]*state
+	overrides map[string]Override
 }
 
 // NewMemoryLimiter constructs a MemoryLimiter with empty state.
 func NewMemoryLimiter() *MemoryLimiter {
 	return &MemoryLimiter{
-		buckets: make(map[string]*state),
+		buckets:   make(map[string]*state),
+		overrides: make(map[string]Override),
 	}
 }
 
 // Allow checks whether a request for the given identity should be allowed under
 // the provided limit. Each call has a fixed cost of 1 token.
 func (m *MemoryLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return m.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN checks whether a request costing n tokens should be allowed under the
+// provided limit. The tokens are only deducted if all n are available.
+func (m *MemoryLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.take(memoryKey(id), limit, n, time.Now())
+}
+
+// AllowMany evaluates each check independently, like a series of AllowN calls,
+// but under a single lock acquisition. Each Result holds either a Decision or
+// the validation error for that check.
+func (m *MemoryLimiter) AllowMany(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, len(checks))
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	now := time.Now()
-	key := string(id.Namespace) + ":" + id.Key
-	st, exists := m.buckets[key]
-	if !exists {
-		m.buckets[key] = &state{
-			tokens:     float64(limit.Burst) - 1,
-			lastRefill: now,
+	for i, c := range checks {
+		if err := checkCost(c.Limit, c.cost()); err != nil {
+			results[i].Err = err
+			continue
 		}
+		results[i].Decision, results[i].Err = m.take(memoryKey(c.ID), c.Limit, c.cost(), now)
+	}
+	return results
+}
+
+// take deducts n tokens from the bucket stored under key if they are all
+// available. It returns ErrCostExceedsBurst if an override lowers the burst
+// below n. The caller must hold m.mu.
+func (m *MemoryLimiter) take(key string, limit Limit, n int64, now time.Time) (Decision, error) {
+	limit = m.limitFor(key, limit, now)
+	if err := checkCapacity(n, limit.Burst); err != nil {
+		return Decision{}, err
+	}
+	st := m.bucket(key, limit, now)
+
+	cost := float64(n)
+	if st.tokens >= cost {
+		st.tokens -= cost
 		return Decision{
 			Allow:      true,
-			Remaining:  limit.Burst - 1,
+			Remaining:  wholeTokens(st.tokens),
 			RetryAfter: 0,
 			ResetTime:  now,
 		}, nil
-	} else {
-		elapsed := now.Sub(st.lastRefill)
-		if elapsed < 0 {
-			elapsed = 0
-		}
-		delta := float64(elapsed) / float64(limit.Period)
-		tokensToAdd := delta * float64(limit.Rate)
-		newBalance := st.tokens + tokensToAdd
-		if newBalance > float64(limit.Burst) {
-			newBalance = float64(limit.Burst)
+	}
+
+	wait := timeToTokens(limit, cost-st.tokens)
+	return Decision{
+		Allow:      false,
+		Remaining:  wholeTokens(st.tokens),
+		RetryAfter: wait,
+		ResetTime:  now.Add(wait),
+	}, nil
+}
+
+// AllowAll evaluates every check under a single lock acquisition and only
+// deducts tokens if all of them admit the request.
+func (m *MemoryLimiter) AllowAll(ctx context.Context, checks []Check) (MultiDecision, error) {
+	return m.allowChecks(checks, false)
+}
+
+// AllowHierarchy evaluates every level of h under a single lock acquisition
+// and only deducts tokens if the whole chain admits the request.
+func (m *MemoryLimiter) AllowHierarchy(ctx context.Context, h Hierarchy) (MultiDecision, error) {
+	return m.allowChecks(h.Levels, h.Borrow)
+}
+
+func (m *MemoryLimiter) allowChecks(checks []Check, borrow bool) (MultiDecision, error) {
+	if err := validateChecks(checks); err != nil {
+		return MultiDecision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	applied := make([]Check, len(checks))
+	states := make([]*state, len(checks))
+	balances := make([]float64, len(checks))
+	for i, c := range checks {
+		applied[i] = c
+		applied[i].Limit = m.limitFor(memoryKey(c.ID), c.Limit, now)
+		if err := checkCapacity(c.cost(), applied[i].Limit.Burst); err != nil {
+			return MultiDecision{}, err
 		}
-		st.tokens = newBalance
-
-		st.lastRefill = now
-
-		if st.tokens >= 1 {
-			st.tokens -= 1
-			return Decision{
-				Allow:      true,
-				Remaining:  int64(st.tokens),
-				RetryAfter: 0,
-				ResetTime:  now,
-			}, nil
+	}
+	for i, c := range applied {
+		states[i] = m.bucket(memoryKey(c.ID), c.Limit, now)
+		balances[i] = states[i].tokens
+	}
+
+	o := plan(applied, balances, borrow)
+	for i, st := range states {
+		st.tokens -= o.deductions[i]
+	}
+	return decide(applied, balances, o, borrow, now), nil
+}
+
+// Reserve is shorthand for ReserveN(ctx, id, limit, 1).
+func (m *MemoryLimiter) Reserve(ctx context.Context, id Identity, limit Limit) (*Reservation, error) {
+	return m.ReserveN(ctx, id, limit, 1)
+}
+
+// ReserveN deducts n tokens now, letting the bucket go into debt if needed, and
+// returns a Reservation telling the caller when it may act.
+func (m *MemoryLimiter) ReserveN(ctx context.Context, id Identity, limit Limit, n int64) (*Reservation, error) {
+	return m.reserveN(id, limit, n, noMaxWait)
+}
+
+// Wait is shorthand for WaitN(ctx, id, limit, 1).
+func (m *MemoryLimiter) Wait(ctx context.Context, id Identity, limit Limit) error {
+	return m.WaitN(ctx, id, limit, 1)
+}
+
+// WaitN blocks until n tokens are available or ctx is done. It fails fast with
+// ErrWaitExceedsDeadline if the wait would outlast the context deadline.
+func (m *MemoryLimiter) WaitN(ctx context.Context, id Identity, limit Limit, n int64) error {
+	return waitN(ctx, func(maxWait time.Duration) (*Reservation, error) {
+		return m.reserveN(id, limit, n, maxWait)
+	})
+}
+
+func (m *MemoryLimiter) reserveN(id Identity, limit Limit, n int64, maxWait time.Duration) (*Reservation, error) {
+	if err := checkCost(limit, n); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	key := memoryKey(id)
+	limit = m.limitFor(key, limit, now)
+	if err := checkCapacity(n, limit.Burst); err != nil {
+		return nil, err
+	}
+	st := m.bucket(key, limit, now)
+
+	cost := float64(n)
+	wait := timeToTokens(limit, cost-st.tokens)
+	if wait > maxWait {
+		return nil, waitTooLong(wait, maxWait)
+	}
+	st.tokens -= cost
+
+	return newReservation(n, now.Add(wait), func(context.Context) error {
+		m.refund(id, limit, n)
+		return nil
+	}), nil
+}
+
+// Reset clears the identity's bucket.
+func (m *MemoryLimiter) Reset(ctx context.Context, id Identity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.buckets, memoryKey(id))
+	return nil
+}
+
+// Refund returns n tokens to the identity's bucket, capped at limit.Burst.
+func (m *MemoryLimiter) Refund(ctx context.Context, id Identity, limit Limit, n int64) error {
+	if err := checkRefund(limit, n); err != nil {
+		return err
+	}
+	m.refund(id, limit, n)
+	return nil
+}
+
+// refund returns n tokens to the identity's bucket, capped at limit.Burst.
+func (m *MemoryLimiter) refund(id Identity, limit Limit, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryKey(id)
+	if _, exists := m.buckets[key]; !exists {
+		return
+	}
+	now := time.Now()
+	limit = m.limitFor(key, limit, now)
+	st := m.bucket(key, limit, now)
+	st.tokens = min(st.tokens+float64(n), float64(limit.Burst))
+}
+
+// Authorize deducts estimate tokens like AllowN and, if they were available,
+// returns an Authorization to settle with the actual cost.
+func (m *MemoryLimiter) Authorize(ctx context.Context, id Identity, limit Limit, estimate int64) (Decision, *Authorization, error) {
+	dec, err := m.AllowN(ctx, id, limit, estimate)
+	if err != nil || !dec.Allow {
+		return dec, nil, err
+	}
+
+	return dec, newAuthorization(estimate, func(_ context.Context, amount, maxDebt int64) error {
+		if amount > 0 {
+			m.refund(id, limit, amount)
 		} else {
-			costPerToken := float64(limit.Period) / float64(limit.Rate)
-			missing := 1.0 - st.tokens
-			waitParams := missing * costPerToken
-			return Decision{
-				Allow:      false,
-				Remaining:  int64(st.tokens),
-				RetryAfter: time.Duration(waitParams),
-				ResetTime:  now.Add(time.Duration(waitParams)),
-			}, nil
+			m.charge(id, limit, -amount, maxDebt)
+		}
+		return nil
+	}), nil
+}
+
+// charge deducts n tokens from the identity's bucket, going into debt of at
+// most maxDebt tokens. A bucket already deeper in debt is left alone.
+func (m *MemoryLimiter) charge(id Identity, limit Limit, n, maxDebt int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryKey(id)
+	now := time.Now()
+	st := m.bucket(key, m.limitFor(key, limit, now), now)
+	floor := min(-float64(maxDebt), st.tokens)
+	st.tokens = max(st.tokens-float64(n), floor)
+}
+
+// Peek reports the identity's current bucket without consuming tokens or
+// updating its refill time. An identity that has never been seen reports a
+// full bucket.
+func (m *MemoryLimiter) Peek(ctx context.Context, id Identity, limit Limit) (BucketState, error) {
+	if err := limit.Validate(); err != nil {
+		return BucketState{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	key := memoryKey(id)
+	limit = m.limitFor(key, limit, now)
+	tokens := float64(limit.Burst)
+	if st, exists := m.buckets[key]; exists {
+		tokens = st.balanceAt(limit, now)
+	}
+	return newBucketState(limit, tokens, now), nil
+}
+
+// bucket returns the state stored under key, refilled up to now. A bucket is
+// created full the first time an identity is seen. The caller must hold m.mu.
+func (m *MemoryLimiter) bucket(key string, limit Limit, now time.Time) *state {
+	st, exists := m.buckets[key]
+	if !exists {
+		st = &state{
+			tokens:     float64(limit.Burst),
+			lastRefill: now,
 		}
+		m.buckets[key] = st
+		return st
+	}
+
+	st.tokens = st.balanceAt(limit, now)
+	st.lastRefill = now
+
+	return st
+}
+
+// balanceAt returns the token balance the bucket will hold at now, capped at
+// limit.Burst. It does not modify st.
+func (st *state) balanceAt(limit Limit, now time.Time) float64 {
+	elapsed := now.Sub(st.lastRefill)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	delta := float64(elapsed) / float64(limit.Period)
+	tokensToAdd := delta * float64(limit.Rate)
+	newBalance := st.tokens + tokensToAdd
+	if newBalance > float64(limit.Burst) {
+		newBalance = float64(limit.Burst)
+	}
+	return newBalance
+}
+
+// newBucketState describes a bucket holding tokens at now.
+func newBucketState(limit Limit, tokens float64, now time.Time) BucketState {
+	return BucketState{
+		Tokens:      tokens,
+		Remaining:   wholeTokens(tokens),
+		TimeToFull:  timeToTokens(limit, float64(limit.Burst)-tokens),
+		NextTokenAt: now.Add(timeToTokens(limit, 1-tokens)),
 	}
+}
+
+// memoryKey builds the map key for an identity: "{namespace}:{key}".
+func memoryKey(id Identity) string {
+	return string(id.Namespace) + ":" + id.Key
+}
 
+// wholeTokens floors a token balance for reporting in a Decision. A bucket in
+// debt reports 0 rather than a negative count.
+func wholeTokens(tokens float64) int64 {
+	if tokens < 0 {
+		return 0
+	}
+	return int64(tokens)
+}
+
+// timeToTokens returns how long the bucket needs to earn the given number of
+// tokens at the limit's refill rate.
+func timeToTokens(limit Limit, tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	costPerToken := float64(limit.Period) / float64(limit.Rate)
+	return time.Duration(tokens * costPerToken)
 }
@@ -0,0 +1,111 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryConcurrencyLimiter is an in-process ConcurrencyLimiter.
+//
+// Like MemoryLimiter, it is safe for concurrent use but its state is local to
+// the process. Use RedisConcurrencyLimiter to share slots across replicas.
+type MemoryConcurrencyLimiter struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	leases map[string]map[string]time.Time
+}
+
+// NewMemoryConcurrencyLimiter constructs a MemoryConcurrencyLimiter whose
+// leases expire after ttl unless renewed. It returns an error wrapping
+// ErrInvalidLimit if ttl is shorter than a millisecond.
+func NewMemoryConcurrencyLimiter(ttl time.Duration) (*MemoryConcurrencyLimiter, error) {
+	if err := checkLeaseTTL(ttl); err != nil {
+		return nil, err
+	}
+	return &MemoryConcurrencyLimiter{
+		ttl:    ttl,
+		leases: make(map[string]map[string]time.Time),
+	}, nil
+}
+
+// Acquire takes one of max slots for the identity, or returns
+// ErrConcurrencyLimitReached.
+func (m *MemoryConcurrencyLimiter) Acquire(ctx context.Context, id Identity, max int64) (*Lease, error) {
+	if err := checkMaxInFlight(max); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	key := memoryKey(id)
+	held := m.live(key, now)
+	if int64(len(held)) >= max {
+		return nil, ErrConcurrencyLimitReached
+	}
+
+	if held == nil {
+		held = make(map[string]time.Time)
+		m.leases[key] = held
+	}
+	token := uniqueToken()
+	held[token] = now.Add(m.ttl)
+
+	return &Lease{
+		id: id,
+		release: func(context.Context) error {
+			m.release(key, token)
+			return nil
+		},
+		renew: func(context.Context) error {
+			return m.renew(key, token)
+		},
+	}, nil
+}
+
+// InFlight returns the number of unexpired leases held by the identity.
+func (m *MemoryConcurrencyLimiter) InFlight(ctx context.Context, id Identity) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return int64(len(m.live(memoryKey(id), time.Now()))), nil
+}
+
+// live drops expired leases held under key and returns the rest. The caller
+// must hold m.mu.
+func (m *MemoryConcurrencyLimiter) live(key string, now time.Time) map[string]time.Time {
+	held := m.leases[key]
+	for token, expires := range held {
+		if !now.Before(expires) {
+			delete(held, token)
+		}
+	}
+	if held != nil && len(held) == 0 {
+		delete(m.leases, key)
+		return nil
+	}
+	return held
+}
+
+func (m *MemoryConcurrencyLimiter) release(key, token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.leases[key], token)
+	m.live(key, time.Now())
+}
+
+func (m *MemoryConcurrencyLimiter) renew(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	held := m.live(key, now)
+	if _, ok := held[token]; !ok {
+		return ErrLeaseExpired
+	}
+	held[token] = now.Add(m.ttl)
+	return nil
+}
@@ -0,0 +1,63 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryCreditLimiter is an in-process CreditLimiter.
+type MemoryCreditLimiter struct {
+	mu       sync.Mutex
+	balances map[string]int64
+}
+
+// NewMemoryCreditLimiter constructs a MemoryCreditLimiter in which every
+// identity starts with no credits.
+func NewMemoryCreditLimiter() *MemoryCreditLimiter {
+	return &MemoryCreditLimiter{balances: make(map[string]int64)}
+}
+
+// Allow deducts one credit if available.
+func (m *MemoryCreditLimiter) Allow(ctx context.Context, id Identity) (Decision, error) {
+	return m.AllowN(ctx, id, 1)
+}
+
+// AllowN deducts n credits if the balance covers all of them.
+func (m *MemoryCreditLimiter) AllowN(ctx context.Context, id Identity, n int64) (Decision, error) {
+	if err := checkAmount(n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryKey(id)
+	balance := m.balances[key]
+	if balance < n {
+		return creditDecision(false, balance), nil
+	}
+	m.balances[key] = balance - n
+	return creditDecision(true, balance-n), nil
+}
+
+// TopUp adds amount credits and returns the new balance.
+func (m *MemoryCreditLimiter) TopUp(ctx context.Context, id Identity, amount int64) (int64, error) {
+	if err := checkAmount(amount); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryKey(id)
+	m.balances[key] += amount
+	return m.balances[key], nil
+}
+
+// Balance returns the current balance.
+func (m *MemoryCreditLimiter) Balance(ctx context.Context, id Identity) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.balances[memoryKey(id)], nil
+}
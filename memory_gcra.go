@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryGCRALimiter is an in-process RateLimiter implementing the generic cell
+// rate algorithm (GCRA). It admits the same traffic as MemoryLimiter, with
+// Decisions of the same meaning, but stores a single theoretical arrival time
+// (TAT) per identity instead of a token balance and refill time.
+type MemoryGCRALimiter struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+// NewMemoryGCRALimiter constructs a MemoryGCRALimiter with empty state.
+func NewMemoryGCRALimiter() *MemoryGCRALimiter {
+	return &MemoryGCRALimiter{tats: make(map[string]time.Time)}
+}
+
+// Allow consumes one token's worth of the identity's allowance.
+func (m *MemoryGCRALimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return m.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN consumes n tokens' worth of the identity's allowance if all of it is
+// available.
+func (m *MemoryGCRALimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryKey(id)
+	dec, tat := gcra(limit, m.tats[key], time.Now(), n)
+	if dec.Allow {
+		m.tats[key] = tat
+	}
+	return dec, nil
+}
+
+// gcra decides whether n requests arriving at now conform, given the stored
+// theoretical arrival time, and returns the TAT to store if they do.
+//
+// Each request pushes the TAT back by one emission interval (Period / Rate).
+// A request conforms if the new TAT is no further ahead of now than Burst
+// intervals, which is equivalent to a full token bucket of Burst tokens. The
+// gcra.lua script uses the same rules.
+func gcra(limit Limit, tat, now time.Time, n int64) (Decision, time.Time) {
+	// Rates above one request per nanosecond are rounded down to it.
+	interval := max(limit.Period/time.Duration(limit.Rate), 1)
+	tolerance := interval * time.Duration(limit.Burst)
+
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval * time.Duration(n))
+
+	if allowAt := next.Add(-tolerance); allowAt.After(now) {
+		wait := allowAt.Sub(now)
+		return Decision{
+			Allow:      false,
+			Remaining:  int64(now.Add(tolerance).Sub(tat) / interval),
+			RetryAfter: wait,
+			ResetTime:  now.Add(wait),
+		}, tat
+	}
+
+	return Decision{
+		Allow:      true,
+		Remaining:  int64(now.Add(tolerance).Sub(next) / interval),
+		RetryAfter: 0,
+		ResetTime:  now,
+	}, next
+}
@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// SetOverride replaces the identity's limit with limit for ttl, or until
+// deleted if ttl is 0.
+func (m *MemoryLimiter) SetOverride(ctx context.Context, id Identity, limit Limit, ttl time.Duration) error {
+	if err := checkOverride(limit, ttl); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o := Override{ID: id, Limit: limit}
+	if ttl > 0 {
+		o.ExpiresAt = time.Now().Add(ttl)
+	}
+	m.overrides[memoryKey(id)] = o
+	return nil
+}
+
+// GetOverride returns the identity's override, if it has one.
+func (m *MemoryLimiter) GetOverride(ctx context.Context, id Identity) (Override, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.override(memoryKey(id), time.Now())
+	return o, ok, nil
+}
+
+// DeleteOverride removes the identity's override, if any.
+func (m *MemoryLimiter) DeleteOverride(ctx context.Context, id Identity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.overrides, memoryKey(id))
+	return nil
+}
+
+// ListOverrides returns the unexpired overrides in a namespace, or in every
+// namespace if ns is empty.
+func (m *MemoryLimiter) ListOverrides(ctx context.Context, ns Namespace) ([]Override, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var list []Override
+	for key, o := range m.overrides {
+		if ns != "" && o.ID.Namespace != ns {
+			continue
+		}
+		if o, ok := m.override(key, now); ok {
+			list = append(list, o)
+		}
+	}
+	return list, nil
+}
+
+// override returns the override stored under key, dropping it if it has
+// expired. The caller must hold m.mu.
+func (m *MemoryLimiter) override(key string, now time.Time) (Override, bool) {
+	o, ok := m.overrides[key]
+	if !ok {
+		return Override{}, false
+	}
+	if !o.ExpiresAt.IsZero() && !now.Before(o.ExpiresAt) {
+		delete(m.overrides, key)
+		return Override{}, false
+	}
+	return o, true
+}
+
+// limitFor returns the limit that applies to the identity stored under key:
+// its override if it has one, otherwise limit. The caller must hold m.mu.
+func (m *MemoryLimiter) limitFor(key string, limit Limit, now time.Time) Limit {
+	if o, ok := m.override(key, now); ok {
+		return o.Limit
+	}
+	return limit
+}
@@ -0,0 +1,49 @@
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// MemoryQuotaLimiter is an in-process QuotaLimiter.
+type MemoryQuotaLimiter struct {
+	memoryWindows
+}
+
+// NewMemoryQuotaLimiter constructs a MemoryQuotaLimiter with empty state.
+func NewMemoryQuotaLimiter() *MemoryQuotaLimiter {
+	return &MemoryQuotaLimiter{memoryWindows: newMemoryWindows()}
+}
+
+// Allow consumes one event from the identity's quota.
+func (m *MemoryQuotaLimiter) Allow(ctx context.Context, id Identity, quota Quota) (Decision, error) {
+	return m.AllowN(ctx, id, quota, 1)
+}
+
+// AllowN consumes n events if they all fit in the current interval.
+func (m *MemoryQuotaLimiter) AllowN(ctx context.Context, id Identity, quota Quota, n int64) (Decision, error) {
+	if err := checkQuotaCost(quota, n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	start, end := quota.window(now)
+	// Each interval counts separately, so one identity can have both a daily
+	// and a monthly quota. Keying on the start too, as the Redis limiter does,
+	// keeps days that begin at different instants in different locations apart.
+	base := memoryKey(id) + "/" + quota.Interval.String() + "/"
+	c := m.counter(base+strconv.FormatInt(start.Unix(), 10), start.Unix())
+	// The previous interval can no longer be consumed from.
+	prev, _ := quota.window(start.Add(-time.Nanosecond))
+	delete(m.counters, base+strconv.FormatInt(prev.Unix(), 10))
+
+	if c.current+n > quota.Max {
+		return quotaDecision(false, quota, c.current, now, end), nil
+	}
+	c.current += n
+	return quotaDecision(true, quota, c.current, now, end), nil
+}
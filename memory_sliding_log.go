@@ -0,0 +1,82 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemorySlidingLogLimiter is an in-process RateLimiter implementing the
+// sliding window log algorithm: it records the time of every admitted event
+// and allows at most limit.Rate events in any rolling limit.Period. Burst is
+// not used.
+//
+// The log makes it exact, at the cost of memory proportional to Rate per
+// identity. It suits low-volume, high-value actions such as login attempts,
+// password resets or SMS sends.
+type MemorySlidingLogLimiter struct {
+	mu   sync.Mutex
+	logs map[string][]time.Time
+}
+
+// NewMemorySlidingLogLimiter constructs a MemorySlidingLogLimiter with empty
+// state.
+func NewMemorySlidingLogLimiter() *MemorySlidingLogLimiter {
+	return &MemorySlidingLogLimiter{
+		logs: make(map[string][]time.Time),
+	}
+}
+
+// Allow records one event if fewer than limit.Rate happened in the last
+// limit.Period.
+func (m *MemorySlidingLogLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return m.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN records n events if they all fit in the current window.
+func (m *MemorySlidingLogLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkWindowCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	key := memoryKey(id)
+
+	// Drop events that have slid out of the window. The log is sorted.
+	log := m.logs[key]
+	cutoff := now.Add(-limit.Period)
+	expired := 0
+	for expired < len(log) && !log[expired].After(cutoff) {
+		expired++
+	}
+	log = log[expired:]
+
+	count := int64(len(log))
+	if count+n <= limit.Rate {
+		for i := int64(0); i < n; i++ {
+			log = append(log, now)
+		}
+		m.logs[key] = log
+		return Decision{
+			Allow:      true,
+			Remaining:  limit.Rate - count - n,
+			RetryAfter: 0,
+			ResetTime:  now,
+		}, nil
+	}
+
+	m.logs[key] = log
+
+	// Enough of the oldest events must expire to make room for n more.
+	freed := log[count+n-limit.Rate-1]
+	wait := freed.Add(limit.Period).Sub(now)
+	return Decision{
+		Allow:      false,
+		Remaining:  limit.Rate - count,
+		RetryAfter: wait,
+		ResetTime:  now.Add(wait),
+	}, nil
+}
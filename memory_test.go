@@ -2,6 +2,7 @@ package limiter
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -87,6 +88,56 @@ func TestMemoryLimiter_Refill(t *testing.T) {
 	}
 }
 
+func TestMemoryLimiter_AllowN(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter()
+
+	limit := Limit{
+		Rate:   10,
+		Period: time.Second,
+		Burst:  10,
+	}
+
+	id := Identity{Namespace: "test", Key: "user_1"}
+
+	dec, err := limiter.AllowN(ctx, id, limit, 7)
+	if err != nil {
+		t.Fatalf("AllowN failed: %v", err)
+	}
+	if !dec.Allow || dec.Remaining != 3 {
+		t.Fatalf("Expected allow with 3 remaining, got %+v", dec)
+	}
+
+	dec, _ = limiter.AllowN(ctx, id, limit, 5)
+	if dec.Allow {
+		t.Fatal("Expected cost 5 to be denied with only 3 tokens left")
+	}
+	// 2 missing tokens at 10/s is ~200ms.
+	if dec.RetryAfter < 150*time.Millisecond || dec.RetryAfter > 200*time.Millisecond {
+		t.Errorf("Expected RetryAfter of ~200ms for 2 missing tokens, got %v", dec.RetryAfter)
+	}
+
+	// A denied AllowN must not consume the tokens that were available.
+	dec, _ = limiter.AllowN(ctx, id, limit, 3)
+	if !dec.Allow {
+		t.Error("Expected remaining 3 tokens to still be available after a denial")
+	}
+}
+
+func TestMemoryLimiter_AllowN_InvalidCost(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 1, Period: time.Second, Burst: 5}
+	id := Identity{Namespace: "test", Key: "user_1"}
+
+	if _, err := limiter.AllowN(ctx, id, limit, 6); !errors.Is(err, ErrCostExceedsBurst) {
+		t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+	}
+	if _, err := limiter.AllowN(ctx, id, limit, 0); !errors.Is(err, ErrInvalidCost) {
+		t.Errorf("Expected ErrInvalidCost, got %v", err)
+	}
+}
+
 // Race Test
 func TestMemoryLimiter_ThreadSafety(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,154 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// counter is the per-identity state of the window-based memory limiters.
+type counter struct {
+	index    int64
+	current  int64
+	previous int64
+}
+
+// memoryWindows is the state shared by the window-based memory limiters.
+type memoryWindows struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+func newMemoryWindows() memoryWindows {
+	return memoryWindows{counters: make(map[string]*counter)}
+}
+
+// counter returns the counter stored under key, rotated into window index.
+// The caller must hold w.mu.
+func (w *memoryWindows) counter(key string, index int64) *counter {
+	c, exists := w.counters[key]
+	if !exists {
+		c = &counter{index: index}
+		w.counters[key] = c
+		return c
+	}
+
+	switch {
+	case index == c.index+1:
+		c.previous, c.current = c.current, 0
+	case index > c.index+1:
+		c.previous, c.current = 0, 0
+	}
+	c.index = index
+	return c
+}
+
+// MemoryFixedWindowLimiter is an in-process RateLimiter implementing a fixed
+// window counter: at most limit.Rate events per epoch-aligned window of
+// limit.Period (for example per calendar minute). Burst is not used.
+//
+// It needs a single counter per identity, but allows up to twice Rate across
+// a window boundary.
+type MemoryFixedWindowLimiter struct {
+	memoryWindows
+}
+
+// NewMemoryFixedWindowLimiter constructs a MemoryFixedWindowLimiter with empty
+// state.
+func NewMemoryFixedWindowLimiter() *MemoryFixedWindowLimiter {
+	return &MemoryFixedWindowLimiter{memoryWindows: newMemoryWindows()}
+}
+
+// Allow counts one event if the current window has room for it.
+func (m *MemoryFixedWindowLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return m.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN counts n events if the current window has room for all of them.
+func (m *MemoryFixedWindowLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkWindowCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	index, elapsed := windowOf(now, limit.Period)
+	c := m.counter(memoryKey(id), index)
+
+	if c.current+n <= limit.Rate {
+		c.current += n
+		return Decision{
+			Allow:      true,
+			Remaining:  limit.Rate - c.current,
+			RetryAfter: 0,
+			ResetTime:  now,
+		}, nil
+	}
+
+	wait := limit.Period - elapsed
+	return Decision{
+		Allow:      false,
+		Remaining:  limit.Rate - c.current,
+		RetryAfter: wait,
+		ResetTime:  now.Add(wait),
+	}, nil
+}
+
+// MemorySlidingWindowLimiter is an in-process RateLimiter implementing the
+// sliding window counter algorithm: it keeps counts for the current and
+// previous epoch-aligned windows and approximates the number of events in
+// the rolling limit.Period by weighting the previous count by its overlap.
+// At most limit.Rate events are allowed; Burst is not used.
+//
+// It needs two counters per identity and smooths out the boundary bursts of a
+// fixed window, at the cost of being approximate.
+type MemorySlidingWindowLimiter struct {
+	memoryWindows
+}
+
+// NewMemorySlidingWindowLimiter constructs a MemorySlidingWindowLimiter with
+// empty state.
+func NewMemorySlidingWindowLimiter() *MemorySlidingWindowLimiter {
+	return &MemorySlidingWindowLimiter{memoryWindows: newMemoryWindows()}
+}
+
+// Allow counts one event if the estimated rolling count has room for it.
+func (m *MemorySlidingWindowLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return m.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN counts n events if the estimated rolling count has room for all of
+// them.
+func (m *MemorySlidingWindowLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkWindowCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	index, elapsed := windowOf(now, limit.Period)
+	c := m.counter(memoryKey(id), index)
+
+	estimate := slidingEstimate(limit.Period, c.previous, c.current, elapsed)
+	if estimate+float64(n) <= float64(limit.Rate) {
+		c.current += n
+		return Decision{
+			Allow:      true,
+			Remaining:  wholeTokens(float64(limit.Rate) - estimate - float64(n)),
+			RetryAfter: 0,
+			ResetTime:  now,
+		}, nil
+	}
+
+	wait := slidingWait(limit, c.previous, c.current, n, elapsed)
+	return Decision{
+		Allow:      false,
+		Remaining:  wholeTokens(float64(limit.Rate) - estimate),
+		RetryAfter: wait,
+		ResetTime:  now.Add(wait),
+	}, nil
+}
@@ -73,3 +73,38 @@ func TestRedisLimiter_Metrics(t *testing.T) {
 		t.Errorf("Expected positive latency, got %v", timings[0])
 	}
 }
+
+func TestRedisLimiter_AllowManyMetrics(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping metrics test: Redis not available (%v)", err)
+	}
+	defer client.Close()
+
+	mock := NewMockRecorder()
+	limiter, err := NewRedisLimiter(client, WithRecorder(mock))
+	if err != nil {
+		t.Fatalf("Failed to create limiter: %v", err)
+	}
+
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 10}
+	ns := Namespace("metrics_batch_" + time.Now().Format("150405.000000000"))
+	limiter.AllowMany(ctx, []Check{
+		{ID: Identity{Namespace: ns, Key: "a"}, Limit: limit},
+		{ID: Identity{Namespace: ns, Key: "b"}, Limit: limit},
+	})
+
+	if val := mock.Counters["ratelimit.call"]; val != 2 {
+		t.Errorf("Expected 'ratelimit.call' counter to be 2, got %v", val)
+	}
+	timings := mock.Timings["ratelimit.latency"]
+	if len(timings) != 2 {
+		t.Fatalf("Expected 2 latency observations, got %d", len(timings))
+	}
+	if timings[0] <= 0 || timings[0] != timings[1] {
+		t.Errorf("Expected both checks to observe the same positive round trip, got %v", timings)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// ErrNoKey is returned by a KeyFunc when the request carries nothing to rate
+// limit it by, such as a missing header.
+var ErrNoKey = errors.New("middleware: no rate limit key")
+
+// KeyFunc extracts the Identity a request is rate limited as.
+type KeyFunc func(r *http.Request) (limiter.Identity, error)
+
+// RemoteIP identifies requests by the host part of r.RemoteAddr, in the "ip"
+// namespace. Behind a proxy every request shares the proxy's address; use a
+// clientip.Extractor's Identity method instead.
+func RemoteIP() KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if host == "" {
+			return limiter.Identity{}, fmt.Errorf("%w: empty remote address", ErrNoKey)
+		}
+		return limiter.Identity{Namespace: "ip", Key: host}, nil
+	}
+}
+
+// Header identifies requests by the value of the named header, in namespace
+// ns. Requests without the header fail with ErrNoKey.
+func Header(name string, ns limiter.Namespace) KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		value := strings.TrimSpace(r.Header.Get(name))
+		if value == "" {
+			return limiter.Identity{}, fmt.Errorf("%w: missing %s header", ErrNoKey, name)
+		}
+		return limiter.Identity{Namespace: ns, Key: value}, nil
+	}
+}
+
+// APIKey identifies requests by the API key in the named header, in the
+// "api_key" namespace. For the Authorization header, a "Bearer " scheme
+// prefix is stripped.
+func APIKey(header string) KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		value := strings.TrimSpace(r.Header.Get(header))
+		if http.CanonicalHeaderKey(header) == "Authorization" {
+			scheme, token, ok := strings.Cut(value, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				return limiter.Identity{}, fmt.Errorf("%w: no bearer token", ErrNoKey)
+			}
+			value = strings.TrimSpace(token)
+		}
+		if value == "" {
+			return limiter.Identity{}, fmt.Errorf("%w: missing API key", ErrNoKey)
+		}
+		return limiter.Identity{Namespace: "api_key", Key: value}, nil
+	}
+}
+
+// Principal identifies requests by the authenticated principal an earlier
+// middleware stored in the request context under key, in the "user"
+// namespace. The value must be a non-empty string or a fmt.Stringer.
+func Principal(key any) KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		var principal string
+		switch v := r.Context().Value(key).(type) {
+		case string:
+			principal = v
+		case fmt.Stringer:
+			principal = v.String()
+		}
+		if principal == "" {
+			return limiter.Identity{}, fmt.Errorf("%w: no principal in context", ErrNoKey)
+		}
+		return limiter.Identity{Namespace: "user", Key: principal}, nil
+	}
+}
+
+// Route identifies requests by the http.ServeMux pattern that matched them,
+// such as "GET /users/{id}", in the "route" namespace. Every caller of a
+// route shares one budget; combine Route with another KeyFunc for a budget
+// per caller and route. The middleware must run inside the ServeMux for the
+// pattern to be set.
+func Route() KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		if r.Pattern == "" {
+			return limiter.Identity{}, fmt.Errorf("%w: no matched route", ErrNoKey)
+		}
+		return limiter.Identity{Namespace: "route", Key: r.Pattern}, nil
+	}
+}
+
+// Combine identifies requests by every key at once, for example a user on a
+// route. Namespaces are joined with "+" and keys with "|", so
+// Combine(Principal(k), Route()) yields {"user+route", "u42|GET /items"}.
+// It fails if any of the KeyFuncs does.
+func Combine(keys ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		namespaces := make([]string, len(keys))
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			id, err := key(r)
+			if err != nil {
+				return limiter.Identity{}, err
+			}
+			namespaces[i] = string(id.Namespace)
+			values[i] = id.Key
+		}
+		return limiter.Identity{
+			Namespace: limiter.Namespace(strings.Join(namespaces, "+")),
+			Key:       strings.Join(values, "|"),
+		}, nil
+	}
+}
+
+// FirstOf uses the first KeyFunc that finds a key, for example the
+// authenticated user and, for anonymous requests, the client IP. Errors other
+// than ErrNoKey are returned immediately.
+func FirstOf(keys ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (limiter.Identity, error) {
+		for _, key := range keys {
+			id, err := key(r)
+			if errors.Is(err, ErrNoKey) {
+				continue
+			}
+			return id, err
+		}
+		return limiter.Identity{}, ErrNoKey
+	}
+}
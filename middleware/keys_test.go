@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+type principalKey struct{}
+
+func TestKeyFuncs(t *testing.T) {
+	req := func(setup func(r *http.Request) *http.Request) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+		r.RemoteAddr = "192.0.2.10:51234"
+		if setup != nil {
+			r = setup(r)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		key     KeyFunc
+		setup   func(r *http.Request) *http.Request
+		want    limiter.Identity
+		wantErr error
+	}{
+		{
+			name: "RemoteIP",
+			key:  RemoteIP(),
+			want: limiter.Identity{Namespace: "ip", Key: "192.0.2.10"},
+		},
+		{
+			name: "RemoteIPv6",
+			key:  RemoteIP(),
+			setup: func(r *http.Request) *http.Request {
+				r.RemoteAddr = "[2001:db8::1]:443"
+				return r
+			},
+			want: limiter.Identity{Namespace: "ip", Key: "2001:db8::1"},
+		},
+		{
+			name: "Header",
+			key:  Header("X-Tenant", "tenant"),
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Tenant", "acme")
+				return r
+			},
+			want: limiter.Identity{Namespace: "tenant", Key: "acme"},
+		},
+		{
+			name:    "HeaderMissing",
+			key:     Header("X-Tenant", "tenant"),
+			wantErr: ErrNoKey,
+		},
+		{
+			name: "APIKey",
+			key:  APIKey("X-API-Key"),
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-API-Key", "k_123")
+				return r
+			},
+			want: limiter.Identity{Namespace: "api_key", Key: "k_123"},
+		},
+		{
+			name: "APIKeyBearer",
+			key:  APIKey("Authorization"),
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("Authorization", "Bearer k_456")
+				return r
+			},
+			want: limiter.Identity{Namespace: "api_key", Key: "k_456"},
+		},
+		{
+			name: "APIKeyBasicRejected",
+			key:  APIKey("Authorization"),
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("Authorization", "Basic dXNlcjpwdw==")
+				return r
+			},
+			wantErr: ErrNoKey,
+		},
+		{
+			name: "Principal",
+			key:  Principal(principalKey{}),
+			setup: func(r *http.Request) *http.Request {
+				return r.WithContext(context.WithValue(r.Context(), principalKey{}, "u42"))
+			},
+			want: limiter.Identity{Namespace: "user", Key: "u42"},
+		},
+		{
+			name:    "PrincipalMissing",
+			key:     Principal(principalKey{}),
+			wantErr: ErrNoKey,
+		},
+		{
+			name: "Route",
+			key:  Route(),
+			setup: func(r *http.Request) *http.Request {
+				r.Pattern = "GET /items/{id}"
+				return r
+			},
+			want: limiter.Identity{Namespace: "route", Key: "GET /items/{id}"},
+		},
+		{
+			name: "Combine",
+			key:  Combine(Principal(principalKey{}), Route()),
+			setup: func(r *http.Request) *http.Request {
+				r.Pattern = "GET /items/{id}"
+				return r.WithContext(context.WithValue(r.Context(), principalKey{}, "u42"))
+			},
+			want: limiter.Identity{Namespace: "user+route", Key: "u42|GET /items/{id}"},
+		},
+		{
+			name:    "CombineFailsIfAnyFails",
+			key:     Combine(RemoteIP(), Principal(principalKey{})),
+			wantErr: ErrNoKey,
+		},
+		{
+			name: "FirstOfFallsBack",
+			key:  FirstOf(Principal(principalKey{}), RemoteIP()),
+			want: limiter.Identity{Namespace: "ip", Key: "192.0.2.10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.key(req(tt.setup))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v (id %v)", tt.wantErr, err, id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, id)
+			}
+		})
+	}
+}
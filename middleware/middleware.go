@@ -0,0 +1,225 @@
+// Package middleware rate limits net/http handlers with any
+// limiter.RateLimiter.
+//
+// A KeyFunc picks the Identity of each request, a fixed Limit or a
+// limiter.PolicyResolver picks its limit, and denied requests get a 429 with
+// the headers chosen through the headers package:
+//
+//	mw, err := middleware.New(l, middleware.APIKey("X-API-Key"),
+//		middleware.WithLimit(limiter.Limit{Rate: 10, Period: time.Second, Burst: 20}),
+//		middleware.WithHeaders(headers.Writer{Style: headers.IETF}),
+//	)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	http.ListenAndServe(":8080", mw.Handler(mux))
+//
+// WithRoutes gives each route its own limit, declared with ServeMux patterns,
+// and a bucket per identity and route.
+//
+// By default the middleware fails open: if the limiter or resolver returns an
+// error, the request is served. WithFailClosed rejects it instead.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/headers"
+)
+
+// DenyHandler writes the response for a request the limiter denied. The rate
+// limit headers have already been set on w.
+type DenyHandler func(w http.ResponseWriter, r *http.Request, dec limiter.Decision)
+
+// ErrorHandler writes the response for a request that could not be checked:
+// its KeyFunc failed, or the middleware fails closed and the limiter or
+// resolver failed.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware rate limits the handlers it wraps.
+type Middleware struct {
+	limiter    limiter.RateLimiter
+	key        KeyFunc
+	resolver   limiter.PolicyResolver
+	routes     *Routes
+	headers    headers.Writer
+	failClosed bool
+	onDeny     DenyHandler
+	onError    ErrorHandler
+}
+
+// Option configures a Middleware.
+type Option func(*Middleware)
+
+// WithLimit applies the same limit to every identity.
+func WithLimit(limit limiter.Limit) Option {
+	return func(m *Middleware) {
+		m.resolver = fixedLimit(limit)
+	}
+}
+
+// fixedLimit resolves every identity to the same Limit.
+type fixedLimit limiter.Limit
+
+func (f fixedLimit) Resolve(ctx context.Context, id limiter.Identity) (limiter.Limit, error) {
+	return limiter.Limit(f), nil
+}
+
+// WithResolver looks up each identity's limit with r.
+func WithResolver(r limiter.PolicyResolver) Option {
+	return func(m *Middleware) {
+		m.resolver = r
+	}
+}
+
+// WithRoutes applies the limit of the route a request matches, and charges
+// it to the request's identity scoped to that route: {"user+route",
+// "u42|POST /v1/orders/{id}"}, so /v1/orders/1 and /v1/orders/2 share a
+// bucket. Requests matching no route fall back to WithLimit or WithResolver,
+// or are not limited if neither is set.
+func WithRoutes(rt *Routes) Option {
+	return func(m *Middleware) {
+		m.routes = rt
+	}
+}
+
+// WithHeaders sets the rate limit headers written on every checked response
+// (default: Retry-After on denials only).
+func WithHeaders(w headers.Writer) Option {
+	return func(m *Middleware) {
+		m.headers = w
+	}
+}
+
+// WithFailClosed rejects requests through the ErrorHandler when the limiter
+// or resolver fails, instead of serving them.
+func WithFailClosed() Option {
+	return func(m *Middleware) {
+		m.failClosed = true
+	}
+}
+
+// WithDenyHandler replaces the default plain-text 429 response.
+func WithDenyHandler(h DenyHandler) Option {
+	return func(m *Middleware) {
+		m.onDeny = h
+	}
+}
+
+// WithErrorHandler replaces the default error response: 400 for requests
+// without a key (ErrNoKey), 503 otherwise.
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(m *Middleware) {
+		m.onError = h
+	}
+}
+
+// New builds a Middleware that checks requests against l, identified by key.
+// One of WithLimit, WithResolver or WithRoutes is required; an invalid fixed
+// limit is reported as limiter.ErrInvalidLimit.
+func New(l limiter.RateLimiter, key KeyFunc, opts ...Option) (*Middleware, error) {
+	m := &Middleware{
+		limiter: l,
+		key:     key,
+		onDeny:  deny,
+		onError: fail,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	switch r := m.resolver.(type) {
+	case nil:
+		if m.routes == nil {
+			return nil, fmt.Errorf("%w: middleware needs WithLimit, WithResolver or WithRoutes", limiter.ErrInvalidLimit)
+		}
+	case fixedLimit:
+		// Catch a bad limit now rather than failing every request.
+		if err := limiter.Limit(r).Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler wraps next so that it only serves requests the limiter allows.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := m.key(r)
+		if err != nil {
+			m.onError(w, r, err)
+			return
+		}
+
+		limit, dec, err := m.check(r, id)
+		if errors.Is(err, errUnlimited) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			if m.failClosed {
+				m.onError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		m.headers.Write(w.Header(), dec, limit)
+		if !dec.Allow {
+			m.onDeny(w, r, dec)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// errUnlimited is returned by check for requests no route or resolver
+// limits.
+var errUnlimited = errors.New("middleware: request is not limited")
+
+// check finds the limit for a request made by id, from its route or the
+// resolver, and asks the limiter about it.
+func (m *Middleware) check(r *http.Request, id limiter.Identity) (limiter.Limit, limiter.Decision, error) {
+	ctx := r.Context()
+
+	var (
+		limit limiter.Limit
+		cost  int64 = 1
+		err   error
+	)
+	if pattern, routeLimit, ok := m.routes.Match(r); ok {
+		id, limit = routeIdentity(id, pattern), routeLimit
+	} else if cr, ok := m.resolver.(limiter.CostResolver); ok {
+		if limit, cost, err = cr.ResolveCost(ctx, id); err != nil {
+			return limiter.Limit{}, limiter.Decision{}, err
+		}
+	} else if m.resolver != nil {
+		if limit, err = m.resolver.Resolve(ctx, id); err != nil {
+			return limiter.Limit{}, limiter.Decision{}, err
+		}
+	} else {
+		return limiter.Limit{}, limiter.Decision{}, errUnlimited
+	}
+
+	dec, err := m.limiter.AllowN(ctx, id, limit, cost)
+	return limit, dec, err
+}
+
+// deny is the default DenyHandler.
+func deny(w http.ResponseWriter, r *http.Request, dec limiter.Decision) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+// fail is the default ErrorHandler.
+func fail(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrNoKey) {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/headers"
+)
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+// failingLimiter returns err from every call.
+type failingLimiter struct{ err error }
+
+func (f failingLimiter) Allow(ctx context.Context, id limiter.Identity, limit limiter.Limit) (limiter.Decision, error) {
+	return limiter.Decision{}, f.err
+}
+
+func (f failingLimiter) AllowN(ctx context.Context, id limiter.Identity, limit limiter.Limit, n int64) (limiter.Decision, error) {
+	return limiter.Decision{}, f.err
+}
+
+func serve(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware_Limits(t *testing.T) {
+	limit := limiter.Limit{Rate: 1, Period: time.Minute, Burst: 2}
+	mw, err := New(limiter.NewMemoryLimiter(), RemoteIP(),
+		WithLimit(limit),
+		WithHeaders(headers.Writer{Style: headers.Legacy}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.Handler(ok)
+
+	for i := range 2 {
+		w := serve(h, "192.0.2.1:1000")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("Request %d: expected X-RateLimit-Limit 2, got %q", i+1, got)
+		}
+	}
+
+	w := serve(h, "192.0.2.1:1001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once the burst is spent, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got == "" || got == "0" {
+		t.Errorf("Expected a Retry-After on the 429, got %q", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected X-RateLimit-Remaining 0, got %q", got)
+	}
+
+	// Another client has its own budget.
+	if w := serve(h, "192.0.2.2:1000"); w.Code != http.StatusOK {
+		t.Errorf("Expected another IP to be allowed, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_Resolver(t *testing.T) {
+	resolver := limiter.NamespaceResolver{
+		"ip": {Rate: 1, Period: time.Minute, Burst: 1},
+	}
+	mw, err := New(limiter.NewMemoryLimiter(), RemoteIP(), WithResolver(resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.Handler(ok)
+
+	if w := serve(h, "192.0.2.1:1000"); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if w := serve(h, "192.0.2.1:1000"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the resolved burst of 1 to be spent, got %d", w.Code)
+	}
+}
+
+// costResolver charges a fixed cost under a fixed limit.
+type costResolver struct {
+	limit limiter.Limit
+	cost  int64
+}
+
+func (c costResolver) Resolve(ctx context.Context, id limiter.Identity) (limiter.Limit, error) {
+	return c.limit, nil
+}
+
+func (c costResolver) ResolveCost(ctx context.Context, id limiter.Identity) (limiter.Limit, int64, error) {
+	return c.limit, c.cost, nil
+}
+
+func TestMiddleware_ResolvedCost(t *testing.T) {
+	resolver := costResolver{limit: limiter.Limit{Rate: 1, Period: time.Minute, Burst: 4}, cost: 2}
+	mw, err := New(limiter.NewMemoryLimiter(), RemoteIP(), WithResolver(resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.Handler(ok)
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if w := serve(h, "192.0.2.1:1000"); w.Code != want {
+			t.Errorf("Request %d: expected %d with a cost of 2 and burst of 4, got %d", i, want, w.Code)
+		}
+	}
+}
+
+func TestMiddleware_Failures(t *testing.T) {
+	limit := limiter.Limit{Rate: 1, Period: time.Second, Burst: 1}
+	broken := failingLimiter{err: limiter.ErrBackendUnavailable}
+
+	t.Run("FailOpen", func(t *testing.T) {
+		mw, _ := New(broken, RemoteIP(), WithLimit(limit))
+		if w := serve(mw.Handler(ok), "192.0.2.1:1000"); w.Code != http.StatusOK {
+			t.Errorf("Expected the request to be served, got %d", w.Code)
+		}
+	})
+
+	t.Run("FailClosed", func(t *testing.T) {
+		mw, _ := New(broken, RemoteIP(), WithLimit(limit), WithFailClosed())
+		if w := serve(mw.Handler(ok), "192.0.2.1:1000"); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503, got %d", w.Code)
+		}
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		mw, _ := New(limiter.NewMemoryLimiter(), Header("X-Tenant", "tenant"), WithLimit(limit))
+		if w := serve(mw.Handler(ok), "192.0.2.1:1000"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for a request without a key, got %d", w.Code)
+		}
+	})
+
+	t.Run("CustomHandlers", func(t *testing.T) {
+		var gotErr error
+		mw, _ := New(broken, RemoteIP(), WithLimit(limit), WithFailClosed(),
+			WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				gotErr = err
+				w.WriteHeader(http.StatusTeapot)
+			}),
+		)
+		if w := serve(mw.Handler(ok), "192.0.2.1:1000"); w.Code != http.StatusTeapot {
+			t.Errorf("Expected the custom error handler's status, got %d", w.Code)
+		}
+		if !errors.Is(gotErr, limiter.ErrBackendUnavailable) {
+			t.Errorf("Expected the limiter's error, got %v", gotErr)
+		}
+
+		var denied limiter.Decision
+		mw, _ = New(limiter.NewMemoryLimiter(), RemoteIP(), WithLimit(limit),
+			WithDenyHandler(func(w http.ResponseWriter, r *http.Request, dec limiter.Decision) {
+				denied = dec
+				http.Error(w, `{"error":"slow down"}`, http.StatusTooManyRequests)
+			}),
+		)
+		h := mw.Handler(ok)
+		serve(h, "192.0.2.1:1000")
+		w := serve(h, "192.0.2.1:1000")
+		if w.Code != http.StatusTooManyRequests || denied.Allow || denied.RetryAfter <= 0 {
+			t.Errorf("Expected the deny handler to get the denial, got %d and %+v", w.Code, denied)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Expected Retry-After to be set before the deny handler runs")
+		}
+	})
+}
+
+func TestNew_Validates(t *testing.T) {
+	if _, err := New(limiter.NewMemoryLimiter(), RemoteIP()); !errors.Is(err, limiter.ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit without a limit or resolver, got %v", err)
+	}
+	if _, err := New(limiter.NewMemoryLimiter(), RemoteIP(), WithLimit(limiter.Limit{Rate: 1})); !errors.Is(err, limiter.ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit for an invalid limit, got %v", err)
+	}
+}
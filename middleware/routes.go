@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// Routes maps http.ServeMux patterns, such as "POST /v1/orders/{id}" or
+// "GET /v1/", to limits. A request gets the limit of the pattern a ServeMux
+// would route it to, so precedence follows the ServeMux rules: the most
+// specific pattern wins, whatever order the routes were declared in.
+type Routes struct {
+	mux    *http.ServeMux
+	limits map[string]limiter.Limit
+}
+
+// NewRoutes builds Routes from patterns and their limits. It fails if a
+// pattern is invalid or conflicts with another, or if a limit is invalid.
+func NewRoutes(limits map[string]limiter.Limit) (*Routes, error) {
+	rt := &Routes{
+		mux:    http.NewServeMux(),
+		limits: make(map[string]limiter.Limit, len(limits)),
+	}
+	for pattern, limit := range limits {
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("route %q: %w", pattern, err)
+		}
+		if err := rt.register(pattern); err != nil {
+			return nil, err
+		}
+		rt.limits[pattern] = limit
+	}
+	return rt, nil
+}
+
+// register adds pattern to the internal ServeMux, turning its panics on
+// invalid or conflicting patterns into errors.
+func (rt *Routes) register(pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("route %q: %v", pattern, r)
+		}
+	}()
+	rt.mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// Match returns the pattern that matches r and its limit. ok is false if no
+// route matches, including when rt is nil.
+func (rt *Routes) Match(r *http.Request) (pattern string, limit limiter.Limit, ok bool) {
+	if rt == nil {
+		return "", limiter.Limit{}, false
+	}
+	_, pattern = rt.mux.Handler(r)
+	limit, ok = rt.limits[pattern]
+	if !ok {
+		return "", limiter.Limit{}, false
+	}
+	return pattern, limit, true
+}
+
+// routeIdentity scopes id to a route, the same way Combine(key, Route())
+// would, so all requests an identity makes to one route share a bucket.
+func routeIdentity(id limiter.Identity, pattern string) limiter.Identity {
+	return limiter.Identity{
+		Namespace: id.Namespace + "+route",
+		Key:       id.Key + "|" + pattern,
+	}
+}
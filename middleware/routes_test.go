@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+var (
+	orderLimit = limiter.Limit{Rate: 5, Period: time.Second, Burst: 2}
+	readLimit  = limiter.Limit{Rate: 100, Period: time.Second, Burst: 100}
+)
+
+func TestRoutes_Match(t *testing.T) {
+	rt, err := NewRoutes(map[string]limiter.Limit{
+		"POST /v1/orders/{id}": orderLimit,
+		"GET /v1/":             readLimit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		wantPattern  string
+		wantLimit    limiter.Limit
+	}{
+		{http.MethodPost, "/v1/orders/1", "POST /v1/orders/{id}", orderLimit},
+		{http.MethodPost, "/v1/orders/2", "POST /v1/orders/{id}", orderLimit},
+		{http.MethodGet, "/v1/orders/1", "GET /v1/", readLimit},
+		{http.MethodHead, "/v1/items", "GET /v1/", readLimit},
+		{http.MethodPost, "/v1/items", "", limiter.Limit{}},
+		{http.MethodGet, "/v2/items", "", limiter.Limit{}},
+		{http.MethodGet, "/v1", "GET /v1/", readLimit}, // redirected to /v1/
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		pattern, limit, ok := rt.Match(r)
+		if pattern != tt.wantPattern || limit != tt.wantLimit || ok != (tt.wantPattern != "") {
+			t.Errorf("%s %s: got %q %v %v, want %q %v", tt.method, tt.path, pattern, limit, ok, tt.wantPattern, tt.wantLimit)
+		}
+	}
+
+	var none *Routes
+	if _, _, ok := none.Match(httptest.NewRequest(http.MethodGet, "/", nil)); ok {
+		t.Error("Expected nil Routes to match nothing")
+	}
+}
+
+func TestNewRoutes_Invalid(t *testing.T) {
+	tests := map[string]map[string]limiter.Limit{
+		"BadPattern":  {"GET /{": readLimit},
+		"BadLimit":    {"GET /": {Rate: 1}},
+		"Conflicting": {"GET /a/{x}": readLimit, "GET /a/{y}": readLimit},
+	}
+	for name, limits := range tests {
+		if _, err := NewRoutes(limits); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMiddleware_Routes(t *testing.T) {
+	rt, err := NewRoutes(map[string]limiter.Limit{
+		"POST /v1/orders/{id}": orderLimit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var charged []limiter.Identity
+	l := recordingLimiter{RateLimiter: limiter.NewMemoryLimiter(), ids: &charged}
+	mw, err := New(l, Principal(principalKey{}), WithRoutes(rt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.Handler(ok)
+
+	send := func(user, method, path string) int {
+		r := httptest.NewRequest(method, path, nil)
+		r = r.WithContext(context.WithValue(r.Context(), principalKey{}, user))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Both orders share u1's bucket of 2 on the route.
+	if code := send("u1", http.MethodPost, "/v1/orders/1"); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := send("u1", http.MethodPost, "/v1/orders/2"); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := send("u1", http.MethodPost, "/v1/orders/3"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the route bucket to be shared across ids, got %d", code)
+	}
+	if code := send("u2", http.MethodPost, "/v1/orders/1"); code != http.StatusOK {
+		t.Errorf("Expected another user to have its own bucket, got %d", code)
+	}
+
+	want := limiter.Identity{Namespace: "user+route", Key: "u1|POST /v1/orders/{id}"}
+	if charged[0] != want {
+		t.Errorf("Expected %v to be charged, got %v", want, charged[0])
+	}
+
+	// Without a fallback limit, other routes are not limited.
+	n := len(charged)
+	for range 5 {
+		if code := send("u1", http.MethodGet, "/v1/orders/1"); code != http.StatusOK {
+			t.Fatalf("Expected an unlimited route to be served, got %d", code)
+		}
+	}
+	if len(charged) != n {
+		t.Errorf("Expected no limiter calls for unmatched routes, got %d", len(charged)-n)
+	}
+}
+
+func TestMiddleware_RoutesFallback(t *testing.T) {
+	rt, _ := NewRoutes(map[string]limiter.Limit{"POST /v1/orders/{id}": orderLimit})
+	mw, err := New(limiter.NewMemoryLimiter(), RemoteIP(),
+		WithRoutes(rt),
+		WithLimit(limiter.Limit{Rate: 1, Period: time.Minute, Burst: 1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.Handler(ok)
+
+	get := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+		return w.Code
+	}
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Errorf("Expected the fallback limit to apply to unmatched routes, got %d", code)
+	}
+}
+
+// recordingLimiter records the identities it is asked about.
+type recordingLimiter struct {
+	limiter.RateLimiter
+	ids *[]limiter.Identity
+}
+
+func (r recordingLimiter) Allow(ctx context.Context, id limiter.Identity, limit limiter.Limit) (limiter.Decision, error) {
+	return r.AllowN(ctx, id, limit, 1)
+}
+
+func (r recordingLimiter) AllowN(ctx context.Context, id limiter.Identity, limit limiter.Limit, n int64) (limiter.Decision, error) {
+	*r.ids = append(*r.ids, id)
+	return r.RateLimiter.AllowN(ctx, id, limit, n)
+}
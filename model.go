@@ -2,6 +2,7 @@ package limiter
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -17,6 +18,30 @@ type Limit struct {
 	Burst  int64
 }
 
+// Validate reports whether the limit can be enforced by a token bucket: Rate,
+// Period and Burst must all be positive. The error wraps ErrInvalidLimit.
+func (l Limit) Validate() error {
+	if err := l.validateWindow(); err != nil {
+		return err
+	}
+	if l.Burst <= 0 {
+		return fmt.Errorf("%w: burst must be positive, got %d", ErrInvalidLimit, l.Burst)
+	}
+	return nil
+}
+
+// validateWindow is Validate for window-based limiters, which do not use
+// Burst.
+func (l Limit) validateWindow() error {
+	if l.Rate <= 0 {
+		return fmt.Errorf("%w: rate must be positive, got %d", ErrInvalidLimit, l.Rate)
+	}
+	if l.Period <= 0 {
+		return fmt.Errorf("%w: period must be positive, got %v", ErrInvalidLimit, l.Period)
+	}
+	return nil
+}
+
 // Decision is the result of a rate-limit check.
 type Decision struct {
 	Allow      bool
@@ -25,6 +50,21 @@ type Decision struct {
 	ResetTime  time.Time
 }
 
+// BucketState is a read-only view of an identity's token bucket, as returned
+// by Peek.
+type BucketState struct {
+	// Tokens is the current balance, including tokens earned since the bucket
+	// was last updated. It is negative while a Reservation is being paid off.
+	Tokens float64
+	// Remaining is Tokens floored to whole tokens, as reported by Decision.
+	Remaining int64
+	// TimeToFull is how long until the bucket is back at Burst.
+	TimeToFull time.Duration
+	// NextTokenAt is when a request costing one token would be allowed. It is
+	// the time of the Peek call if a token is already available.
+	NextTokenAt time.Time
+}
+
 // Identity uniquely identifies the subject being rate-limited (for example,
 // a user ID, an API key, or an IP address).
 type Identity struct {
@@ -34,7 +74,58 @@ type Identity struct {
 
 // RateLimiter performs token-bucket admission control.
 type RateLimiter interface {
+	// Allow consumes a single token. It is equivalent to AllowN with n == 1.
 	Allow(ctx context.Context, id Identity, limit Limit) (Decision, error)
+
+	// AllowN consumes n tokens at once, for requests that are more expensive
+	// than others (batch calls, byte-based quotas). Either all n tokens are
+	// consumed or none are. It returns ErrCostExceedsBurst if n is larger than
+	// limit.Burst, since such a request could never be admitted.
+	AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error)
+}
+
+// Reserver is implemented by limiters that can schedule a request for later
+// instead of rejecting it, for callers that would rather pace themselves than
+// be turned away.
+type Reserver interface {
+	// ReserveN deducts n tokens immediately, even if the bucket has to go into
+	// debt, and reports when the caller may act on them.
+	ReserveN(ctx context.Context, id Identity, limit Limit, n int64) (*Reservation, error)
+
+	// WaitN blocks until n tokens are available or ctx is done. If ctx has a
+	// deadline that would pass before the tokens are available, WaitN returns
+	// ErrWaitExceedsDeadline immediately without consuming anything.
+	WaitN(ctx context.Context, id Identity, limit Limit, n int64) error
+}
+
+// Authorizer is implemented by limiters that support two-phase admission, for
+// workloads whose real cost is only known once the request completes.
+type Authorizer interface {
+	// Authorize deducts an estimated cost exactly like AllowN. If the
+	// Decision allows the request, the returned Authorization must be settled
+	// with the actual cost afterwards; otherwise it is nil.
+	Authorize(ctx context.Context, id Identity, limit Limit, estimate int64) (Decision, *Authorization, error)
+}
+
+// Peeker is implemented by limiters that can report an identity's bucket
+// without consuming tokens, for dashboards and "quota left" endpoints.
+type Peeker interface {
+	Peek(ctx context.Context, id Identity, limit Limit) (BucketState, error)
+}
+
+// Admin is implemented by limiters that allow an identity's bucket to be
+// adjusted, for support tooling and handlers that want to give tokens back. It
+// is kept separate from RateLimiter so read-only consumers are not forced to
+// implement it.
+type Admin interface {
+	// Reset clears the identity's bucket; its next request starts from a full
+	// bucket.
+	Reset(ctx context.Context, id Identity) error
+
+	// Refund returns n tokens to the identity's bucket, capped at limit.Burst.
+	// Refunding an identity that has no bucket is a no-op, since a missing
+	// bucket is already full.
+	Refund(ctx context.Context, id Identity, limit Limit, n int64) error
 }
 
 // MetricsRecorder defines the interface for collecting telemetry.
@@ -0,0 +1,218 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Check pairs an identity with the limit its bucket is evaluated against.
+type Check struct {
+	ID    Identity
+	Limit Limit
+	// Cost is the number of tokens the check consumes. Zero means 1.
+	Cost int64
+}
+
+func (c Check) cost() int64 {
+	if c.Cost == 0 {
+		return 1
+	}
+	return c.Cost
+}
+
+// PerLimit builds one Check per limit for the same identity, for policies
+// such as "10/second AND 1000/hour". Each limit needs its own bucket, so the
+// period is appended to the key ("user_123/1h0m0s"); limits passed together
+// must therefore have distinct periods.
+func PerLimit(id Identity, limits ...Limit) []Check {
+	checks := make([]Check, len(limits))
+	for i, limit := range limits {
+		checks[i] = Check{
+			ID:    Identity{Namespace: id.Namespace, Key: id.Key + "/" + limit.Period.String()},
+			Limit: limit,
+		}
+	}
+	return checks
+}
+
+// Result is the outcome of one check evaluated by AllowMany: either a
+// Decision or the error that prevented one.
+type Result struct {
+	Decision Decision
+	Err      error
+}
+
+// BatchLimiter is implemented by limiters that can evaluate many independent
+// checks in one call, for gateways that check several identities per request
+// and for batch endpoints.
+type BatchLimiter interface {
+	// AllowMany evaluates each check as if by AllowN. Unlike AllowAll, every
+	// check is admitted or denied on its own. The results are in the same
+	// order as checks.
+	AllowMany(ctx context.Context, checks []Check) []Result
+}
+
+// MultiDecision is the result of evaluating several checks together.
+//
+// The embedded Decision is the aggregate: Allow is true only if every check
+// admitted the request, Remaining is the smallest remaining balance and
+// RetryAfter is the longest wait of any check.
+type MultiDecision struct {
+	Decision
+
+	// Binding is the index of the check that determined the outcome: the one
+	// with the longest RetryAfter when denied, or the one with the fewest
+	// tokens left when allowed.
+	Binding int
+
+	// Decisions holds the individual result of each check, in order. When the
+	// request is denied nothing is deducted anywhere, so an individual
+	// Decision may report Allow (its bucket alone had enough tokens) with
+	// Remaining showing its untouched balance.
+	Decisions []Decision
+}
+
+// MultiLimiter is implemented by limiters that can evaluate several checks
+// atomically: tokens are deducted from every bucket or from none.
+type MultiLimiter interface {
+	AllowAll(ctx context.Context, checks []Check) (MultiDecision, error)
+}
+
+// validateChecks rejects empty check lists, invalid costs and checks that
+// share a bucket, which would otherwise be double-counted.
+func validateChecks(checks []Check) error {
+	if len(checks) == 0 {
+		return fmt.Errorf("%w: at least one check is required", ErrInvalidLimit)
+	}
+
+	seen := make(map[Identity]struct{}, len(checks))
+	for _, c := range checks {
+		if err := checkCost(c.Limit, c.cost()); err != nil {
+			return err
+		}
+		if _, dup := seen[c.ID]; dup {
+			return fmt.Errorf("%w: duplicate check for %s:%s", ErrInvalidLimit, c.ID.Namespace, c.ID.Key)
+		}
+		seen[c.ID] = struct{}{}
+	}
+	return nil
+}
+
+// outcome is how a set of checks is settled against its buckets.
+type outcome struct {
+	// deductions holds the tokens taken from each bucket, all zero if denied.
+	deductions []float64
+	// short is the index of a check that could not pay, or -1 if admitted.
+	short int
+	// need is what the short check had to pay, including any shortfall
+	// borrowed by the checks after it.
+	need float64
+}
+
+// plan works out what each bucket pays for a request. Without borrowing,
+// every check pays its own cost. With borrowing, checks are visited from the
+// last (innermost) to the first, and a check that cannot cover what it owes
+// pays what it has and passes the shortfall on to the check before it; the
+// first check cannot borrow. The Redis script applies the same rules.
+func plan(checks []Check, balances []float64, borrow bool) outcome {
+	o := outcome{deductions: make([]float64, len(checks)), short: -1}
+
+	carry := 0.0
+	for i := len(checks) - 1; i >= 0; i-- {
+		need := float64(checks[i].cost()) + carry
+		carry = 0
+
+		switch {
+		case balances[i] >= need:
+			o.deductions[i] = need
+		case borrow && i > 0:
+			o.deductions[i] = max(balances[i], 0)
+			carry = need - o.deductions[i]
+		default:
+			o.short, o.need = i, need
+		}
+
+		if o.short >= 0 && borrow {
+			// The checks before i never saw the request.
+			break
+		}
+	}
+
+	if o.short >= 0 {
+		clear(o.deductions)
+	}
+	return o
+}
+
+// decide builds the MultiDecision for checks whose buckets held balances at
+// now, before o was applied.
+func decide(checks []Check, balances []float64, o outcome, borrow bool, now time.Time) MultiDecision {
+	allowed := o.short < 0
+	decisions := make([]Decision, len(checks))
+
+	for i, c := range checks {
+		need := float64(c.cost())
+		if i == o.short {
+			need = o.need
+		}
+
+		switch {
+		case allowed:
+			decisions[i] = Decision{
+				Allow:     true,
+				Remaining: wholeTokens(balances[i] - o.deductions[i]),
+				ResetTime: now,
+			}
+		case balances[i] >= need:
+			decisions[i] = Decision{
+				Allow:     true,
+				Remaining: wholeTokens(balances[i]),
+				ResetTime: now,
+			}
+		default:
+			wait := timeToTokens(c.Limit, need-balances[i])
+			decisions[i] = Decision{
+				Allow:      false,
+				Remaining:  wholeTokens(balances[i]),
+				RetryAfter: wait,
+				ResetTime:  now.Add(wait),
+			}
+		}
+	}
+
+	md := combine(decisions, allowed, now)
+	if !allowed && borrow {
+		// A check that borrowed may be short on its own, but only the level
+		// that ran out for the whole chain decides when a retry can succeed.
+		md.Binding = o.short
+		md.RetryAfter = decisions[o.short].RetryAfter
+		md.ResetTime = decisions[o.short].ResetTime
+	}
+	return md
+}
+
+// combine aggregates individual decisions into a MultiDecision.
+func combine(decisions []Decision, allowed bool, now time.Time) MultiDecision {
+	md := MultiDecision{Decisions: decisions}
+	md.Allow = allowed
+	md.Remaining = decisions[0].Remaining
+
+	for i, d := range decisions {
+		if d.Remaining < md.Remaining {
+			md.Remaining = d.Remaining
+			if allowed {
+				md.Binding = i
+			}
+		}
+		if d.RetryAfter > md.RetryAfter {
+			md.RetryAfter = d.RetryAfter
+			if !allowed {
+				md.Binding = i
+			}
+		}
+	}
+
+	md.ResetTime = now.Add(md.RetryAfter)
+	return md
+}
@@ -0,0 +1,103 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testMultiLimiter(t *testing.T, l interface {
+	MultiLimiter
+	Peeker
+}, newID func(string) Identity) {
+	ctx := context.Background()
+
+	t.Run("AllOrNothing", func(t *testing.T) {
+		perSecond := Limit{Rate: 10, Period: time.Second, Burst: 10}
+		perHour := Limit{Rate: 3, Period: time.Hour, Burst: 3}
+		checks := PerLimit(newID("multi"), perSecond, perHour)
+
+		for i := 0; i < 3; i++ {
+			md, err := l.AllowAll(ctx, checks)
+			if err != nil {
+				t.Fatalf("AllowAll failed: %v", err)
+			}
+			if !md.Allow {
+				t.Fatalf("Request %d was unexpectedly denied", i)
+			}
+		}
+
+		md, err := l.AllowAll(ctx, checks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Allow {
+			t.Fatal("Expected the hourly limit to deny the 4th request")
+		}
+		if md.Binding != 1 {
+			t.Errorf("Expected the hourly limit (index 1) to be binding, got %d", md.Binding)
+		}
+		if md.RetryAfter < 19*time.Minute {
+			t.Errorf("Expected RetryAfter of ~20m from the hourly limit, got %v", md.RetryAfter)
+		}
+		if !md.Decisions[0].Allow {
+			t.Error("Expected the per-second check to report it could have admitted the request")
+		}
+
+		// The denied request must not have consumed from the per-second bucket.
+		st, err := l.Peek(ctx, checks[0].ID, perSecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Remaining != 7 {
+			t.Errorf("Expected 7 tokens left in the per-second bucket, got %d", st.Remaining)
+		}
+	})
+
+	t.Run("SeveralIdentities", func(t *testing.T) {
+		limit := Limit{Rate: 1, Period: time.Minute, Burst: 5}
+		user := Check{ID: newID("user"), Limit: limit, Cost: 2}
+		tenant := Check{ID: newID("tenant"), Limit: limit, Cost: 4}
+
+		md, err := l.AllowAll(ctx, []Check{user, tenant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !md.Allow || md.Remaining != 1 || md.Binding != 1 {
+			t.Fatalf("Expected allow bound by the tenant with 1 remaining, got %+v", md)
+		}
+
+		md, err = l.AllowAll(ctx, []Check{user, tenant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Allow || md.Binding != 1 {
+			t.Errorf("Expected the tenant to deny the second request, got %+v", md)
+		}
+	})
+
+	t.Run("DuplicateBucket", func(t *testing.T) {
+		c := Check{ID: newID("dup"), Limit: Limit{Rate: 1, Period: time.Second, Burst: 1}}
+		if _, err := l.AllowAll(ctx, []Check{c, c}); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Expected ErrInvalidLimit for two checks sharing a bucket, got %v", err)
+		}
+	})
+
+	t.Run("NoChecks", func(t *testing.T) {
+		if _, err := l.AllowAll(ctx, nil); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Expected ErrInvalidLimit for an empty batch, got %v", err)
+		}
+	})
+}
+
+func TestMemoryLimiter_AllowAll(t *testing.T) {
+	testMultiLimiter(t, NewMemoryLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisLimiter_AllowAll(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testMultiLimiter(t, limiter, uniqueIdentity)
+}
@@ -0,0 +1,20 @@
+-- Prelude shared by the token bucket scripts, which receive each identity's
+-- override key alongside its bucket key. An override is a hash holding a
+-- Limit's rate, period (nanoseconds) and burst; while it exists it replaces
+-- the limit sent by the caller. The raw fields are returned too, so scripts
+-- can report the limit they applied.
+local function apply_override(key, rate, capacity)
+    local o = redis.call('HMGET', key, 'rate', 'period', 'burst')
+    local o_rate, o_period, o_burst = tonumber(o[1]), tonumber(o[2]), tonumber(o[3])
+    if not (o_rate and o_period and o_burst) then
+        return rate, capacity, nil
+    end
+    return o_rate / (o_period / 1e9), o_burst, o
+end
+
+
+-- The caller's cost was checked against its own burst; an override with a
+-- smaller burst can still make it unsatisfiable.
+local function cost_exceeds_burst(cost, capacity)
+    return redis.error_reply('COSTEXCEEDSBURST cost ' .. cost .. ', capacity ' .. capacity)
+end
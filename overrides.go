@@ -0,0 +1,50 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Override temporarily replaces the Limit callers pass for one identity, for
+// example to double a customer's limit for 48 hours without a deploy.
+//
+// Overrides apply to the token bucket limiters (MemoryLimiter and
+// RedisLimiter) in every operation that takes a Limit. Costs are still
+// checked against the caller's Limit before the override is consulted.
+type Override struct {
+	ID    Identity
+	Limit Limit
+	// ExpiresAt is when the override lapses, or the zero time if it never
+	// does.
+	ExpiresAt time.Time
+}
+
+// OverrideStore is implemented by limiters that support per-identity
+// overrides.
+type OverrideStore interface {
+	// SetOverride replaces the identity's limit with limit for ttl, or until
+	// deleted if ttl is 0. It replaces any existing override.
+	SetOverride(ctx context.Context, id Identity, limit Limit, ttl time.Duration) error
+
+	// GetOverride returns the identity's override, if it has one.
+	GetOverride(ctx context.Context, id Identity) (Override, bool, error)
+
+	// DeleteOverride removes the identity's override, if any.
+	DeleteOverride(ctx context.Context, id Identity) error
+
+	// ListOverrides returns the overrides in a namespace, or in every
+	// namespace if ns is empty, in no particular order.
+	ListOverrides(ctx context.Context, ns Namespace) ([]Override, error)
+}
+
+// checkOverride validates the arguments of SetOverride.
+func checkOverride(limit Limit, ttl time.Duration) error {
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return fmt.Errorf("%w: override ttl must not be negative, got %v", ErrInvalidLimit, ttl)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// overrideLimiter is the subset of a token bucket limiter the override tests
+// exercise.
+type overrideLimiter interface {
+	RateLimiter
+	BatchLimiter
+	MultiLimiter
+	Reserver
+	Peeker
+	OverrideStore
+}
+
+func testOverrides(t *testing.T, l overrideLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Period: time.Minute, Burst: 2}
+	raised := Limit{Rate: 1, Period: time.Minute, Burst: 5}
+
+	t.Run("RaisesLimit", func(t *testing.T) {
+		id := newID("raise")
+		if err := l.SetOverride(ctx, id, raised, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range 5 {
+			if dec, err := l.Allow(ctx, id, limit); err != nil || !dec.Allow {
+				t.Fatalf("Request %d: expected the override to allow it, got %+v (err %v)", i+1, dec, err)
+			}
+		}
+		if dec, _ := l.Allow(ctx, id, limit); dec.Allow {
+			t.Error("Expected the overridden burst of 5 to be exhausted")
+		}
+	})
+
+	t.Run("LowersLimit", func(t *testing.T) {
+		id := newID("lower")
+		lowered := Limit{Rate: 1, Period: time.Minute, Burst: 1}
+		if err := l.SetOverride(ctx, id, lowered, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if dec, _ := l.Allow(ctx, id, limit); !dec.Allow {
+			t.Fatal("Expected the first request to be allowed")
+		}
+		dec, err := l.Allow(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Error("Expected the overridden burst of 1 to be exhausted")
+		}
+
+		st, err := l.Peek(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.TimeToFull < 50*time.Second {
+			t.Errorf("Expected TimeToFull near a minute under the override, got %v", st.TimeToFull)
+		}
+	})
+
+	t.Run("AppliesToAllowAll", func(t *testing.T) {
+		user, tenant := newID("multi_user"), newID("multi_tenant")
+		if err := l.SetOverride(ctx, tenant, raised, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+
+		// Without the override the tenant's burst of 2 would bind first.
+		checks := []Check{
+			{ID: user, Limit: Limit{Rate: 1, Period: time.Minute, Burst: 3}},
+			{ID: tenant, Limit: limit},
+		}
+		for i := range 3 {
+			if md, err := l.AllowAll(ctx, checks); err != nil || !md.Allow {
+				t.Fatalf("Request %d: expected it to be allowed, got %+v (err %v)", i+1, md, err)
+			}
+		}
+		md, err := l.AllowAll(ctx, checks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Allow || md.Binding != 0 {
+			t.Errorf("Expected the user to bind, got %+v", md)
+		}
+		if md.Decisions[1].Remaining != 2 {
+			t.Errorf("Expected the tenant to have 2 of its overridden 5 left, got %d", md.Decisions[1].Remaining)
+		}
+	})
+
+	t.Run("GetListDelete", func(t *testing.T) {
+		base := newID("list")
+		ns := Namespace(base.Key)
+		a := Identity{Namespace: ns, Key: "a"}
+		b := Identity{Namespace: ns, Key: "b"}
+		if err := l.SetOverride(ctx, a, raised, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.SetOverride(ctx, b, raised, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		o, ok, err := l.GetOverride(ctx, a)
+		if err != nil || !ok {
+			t.Fatalf("Expected an override for a, got %v (err %v)", ok, err)
+		}
+		if o.ID != a || o.Limit != raised {
+			t.Errorf("Expected %v for %v, got %+v", raised, a, o)
+		}
+		if until := time.Until(o.ExpiresAt); until <= 0 || until > time.Minute {
+			t.Errorf("Expected an expiry within a minute, got %v", o.ExpiresAt)
+		}
+
+		list, err := l.ListOverrides(ctx, ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 {
+			t.Fatalf("Expected 2 overrides in %q, got %+v", ns, list)
+		}
+		for _, o := range list {
+			if o.ID == b && !o.ExpiresAt.IsZero() {
+				t.Errorf("Expected b to never expire, got %v", o.ExpiresAt)
+			}
+		}
+
+		if err := l.DeleteOverride(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, _ := l.GetOverride(ctx, a); ok {
+			t.Error("Expected the override to be gone after DeleteOverride")
+		}
+		if list, _ := l.ListOverrides(ctx, ns); len(list) != 1 || list[0].ID != b {
+			t.Errorf("Expected only b to remain, got %+v", list)
+		}
+	})
+
+	t.Run("CostExceedsOverriddenBurst", func(t *testing.T) {
+		id := newID("lower_cost")
+		lowered := Limit{Rate: 1, Period: time.Minute, Burst: 1}
+		if err := l.SetOverride(ctx, id, lowered, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+
+		// A cost of 2 fits the caller's burst but never the override's.
+		if _, err := l.AllowN(ctx, id, limit, 2); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst from AllowN, got %v", err)
+		}
+		results := l.AllowMany(ctx, []Check{{ID: id, Limit: limit, Cost: 2}})
+		if !errors.Is(results[0].Err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst from AllowMany, got %v", results[0].Err)
+		}
+		if _, err := l.AllowAll(ctx, []Check{{ID: id, Limit: limit, Cost: 2}}); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst from AllowAll, got %v", err)
+		}
+		if _, err := l.ReserveN(ctx, id, limit, 2); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst from ReserveN, got %v", err)
+		}
+
+		if dec, err := l.Allow(ctx, id, limit); err != nil || !dec.Allow {
+			t.Errorf("Expected the bucket to be untouched, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("ListNamespaceWithColon", func(t *testing.T) {
+		parent := Namespace(newID("colon").Key)
+		id := Identity{Namespace: parent + ":team", Key: "k"}
+		if err := l.SetOverride(ctx, id, raised, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		defer l.DeleteOverride(ctx, id)
+
+		list, err := l.ListOverrides(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.ContainsFunc(list, func(o Override) bool { return o.ID == id }) {
+			t.Errorf("Expected %v among all overrides, got %+v", id, list)
+		}
+		if list, _ := l.ListOverrides(ctx, parent); len(list) != 0 {
+			t.Errorf("Expected no overrides in %q, got %+v", parent, list)
+		}
+		if list, _ := l.ListOverrides(ctx, id.Namespace); len(list) != 1 || list[0].ID != id {
+			t.Errorf("Expected only %v in %q, got %+v", id, id.Namespace, list)
+		}
+	})
+
+	t.Run("Expires", func(t *testing.T) {
+		id := newID("expire")
+		if err := l.SetOverride(ctx, id, raised, 50*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		if _, ok, _ := l.GetOverride(ctx, id); ok {
+			t.Fatal("Expected the override to have expired")
+		}
+		for range 2 {
+			l.Allow(ctx, id, limit)
+		}
+		if dec, _ := l.Allow(ctx, id, limit); dec.Allow {
+			t.Error("Expected the caller's burst of 2 to apply once the override expired")
+		}
+	})
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		id := newID("invalid")
+		if err := l.SetOverride(ctx, id, Limit{Rate: 1, Period: time.Second}, 0); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Expected ErrInvalidLimit for a zero burst, got %v", err)
+		}
+		if err := l.SetOverride(ctx, id, raised, -time.Second); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Expected ErrInvalidLimit for a negative ttl, got %v", err)
+		}
+	})
+}
+
+func TestMemoryLimiter_Overrides(t *testing.T) {
+	testOverrides(t, NewMemoryLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisLimiter_Overrides(t *testing.T) {
+	l, _ := newIntegrationLimiter(t)
+	testOverrides(t, l, uniqueIdentity)
+}
+
+func TestRedisLimiter_ReservedNamespace(t *testing.T) {
+	l, _ := newIntegrationLimiter(t)
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Period: time.Minute, Burst: 1}
+
+	// {override, api:k} would share a key with the override of {api, k}.
+	target := uniqueIdentity("reserved")
+	if err := l.SetOverride(ctx, target, limit, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	defer l.DeleteOverride(ctx, target)
+
+	for _, ns := range []Namespace{"override", "override:" + target.Namespace} {
+		id := Identity{Namespace: ns, Key: target.Key}
+		if _, err := l.Allow(ctx, id, limit); !errors.Is(err, ErrReservedNamespace) {
+			t.Errorf("%s: expected ErrReservedNamespace from Allow, got %v", ns, err)
+		}
+		if _, err := l.AllowAll(ctx, []Check{{ID: id, Limit: limit}}); !errors.Is(err, ErrReservedNamespace) {
+			t.Errorf("%s: expected ErrReservedNamespace from AllowAll, got %v", ns, err)
+		}
+		if err := l.Reset(ctx, id); !errors.Is(err, ErrReservedNamespace) {
+			t.Errorf("%s: expected ErrReservedNamespace from Reset, got %v", ns, err)
+		}
+	}
+
+	if _, ok, err := l.GetOverride(ctx, target); err != nil || !ok {
+		t.Errorf("Expected the override to be untouched, got %v (err %v)", ok, err)
+	}
+	// Every other limiter's keys are reserved the same way.
+	for _, kind := range keyKinds {
+		id := Identity{Namespace: Namespace(kind+":") + target.Namespace, Key: target.Key}
+		if _, err := l.Allow(ctx, id, limit); !errors.Is(err, ErrReservedNamespace) {
+			t.Errorf("%s: expected ErrReservedNamespace from Allow, got %v", id.Namespace, err)
+		}
+	}
+	if _, err := l.Allow(ctx, Identity{Namespace: "overrides", Key: "k"}, limit); err != nil {
+		t.Errorf("Expected other namespaces to be allowed, got %v", err)
+	}
+}
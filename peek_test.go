@@ -0,0 +1,64 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testPeeker(t *testing.T, l interface {
+	RateLimiter
+	Peeker
+}, id Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 5}
+
+	st, err := l.Peek(ctx, id, limit)
+	if err != nil {
+		t.Fatalf("Peek failed: %v", err)
+	}
+	if st.Remaining != 5 || st.TimeToFull != 0 {
+		t.Errorf("Expected a full bucket for an unseen identity, got %+v", st)
+	}
+
+	if _, err := l.AllowN(ctx, id, limit, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		st, err = l.Peek(ctx, id, limit)
+		if err != nil {
+			t.Fatalf("Peek failed: %v", err)
+		}
+	}
+	if st.Remaining != 0 {
+		t.Errorf("Expected 0 remaining after draining the bucket, got %d", st.Remaining)
+	}
+	// 5 tokens at 10/s take ~500ms to earn back.
+	if st.TimeToFull < 400*time.Millisecond || st.TimeToFull > 500*time.Millisecond {
+		t.Errorf("Expected TimeToFull of ~500ms, got %v", st.TimeToFull)
+	}
+	if wait := time.Until(st.NextTokenAt); wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("Expected next token within 100ms, got %v", wait)
+	}
+
+	time.Sleep(120 * time.Millisecond)
+
+	// Repeated peeks must not have consumed anything or reset the refill.
+	dec, err := l.Allow(ctx, id, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Allow {
+		t.Error("Expected a refilled token to be available after peeking")
+	}
+}
+
+func TestMemoryLimiter_Peek(t *testing.T) {
+	testPeeker(t, NewMemoryLimiter(), Identity{Namespace: "test", Key: "peek"})
+}
+
+func TestRedisLimiter_Peek(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testPeeker(t, limiter, uniqueIdentity("peek"))
+}
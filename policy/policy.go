@@ -0,0 +1,219 @@
+// Package policy loads named rate-limit policies from JSON configuration, so
+// limits can be changed without editing handlers.
+//
+// A configuration file lists one policy per namespace:
+//
+//	{
+//	  "policies": [
+//	    {"name": "api", "namespace": "api_key", "rate": 100, "period": "1s", "burst": 200},
+//	    {"name": "login", "namespace": "login", "rate": 5, "period": "15m", "algorithm": "sliding_log"}
+//	  ]
+//	}
+//
+// A Store serves the policies of a file, reloading it when it changes, and
+// is a limiter.PolicyResolver mapping any Identity to the Limit of its
+// namespace.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// ErrNoPolicy is returned when no policy is configured for an identity's
+// namespace. It is limiter.ErrNoPolicy, so a Store can be chained with other
+// resolvers.
+var ErrNoPolicy = limiter.ErrNoPolicy
+
+// Algorithm names the limiter implementation a policy is meant for. The
+// policy package does not construct limiters itself; callers use the
+// algorithm to pick one.
+type Algorithm string
+
+const (
+	// TokenBucket selects limiter.RedisLimiter or limiter.MemoryLimiter. It is
+	// the default.
+	TokenBucket Algorithm = "token_bucket"
+	// GCRA selects the GCRA limiters.
+	GCRA Algorithm = "gcra"
+	// SlidingLog selects the sliding window log limiters.
+	SlidingLog Algorithm = "sliding_log"
+	// SlidingWindow selects the sliding window counter limiters.
+	SlidingWindow Algorithm = "sliding_window"
+	// FixedWindow selects the fixed window limiters.
+	FixedWindow Algorithm = "fixed_window"
+)
+
+// windowed reports whether the algorithm counts events per window, in which
+// case Burst is not used and a request may cost up to Rate.
+func (a Algorithm) windowed() bool {
+	return a == SlidingLog || a == SlidingWindow || a == FixedWindow
+}
+
+func (a Algorithm) valid() bool {
+	return a == TokenBucket || a == GCRA || a.windowed()
+}
+
+// Duration is a time.Duration written in configuration as a string such as
+// "1s", "15m" or "24h".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"1s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Policy is a named limit for one namespace.
+type Policy struct {
+	Name      string            `json:"name"`
+	Namespace limiter.Namespace `json:"namespace"`
+	Rate      int64             `json:"rate"`
+	Period    Duration          `json:"period"`
+	// Burst defaults to Rate when omitted.
+	Burst int64 `json:"burst,omitempty"`
+	// Algorithm defaults to TokenBucket when omitted.
+	Algorithm Algorithm `json:"algorithm,omitempty"`
+	// Cost is the number of tokens a request takes. It defaults to 1.
+	Cost int64 `json:"cost,omitempty"`
+}
+
+// Limit returns the policy as a limiter.Limit.
+func (p Policy) Limit() limiter.Limit {
+	return limiter.Limit{
+		Rate:   p.Rate,
+		Period: time.Duration(p.Period),
+		Burst:  p.Burst,
+	}
+}
+
+// withDefaults fills in the fields that may be omitted.
+func (p Policy) withDefaults() Policy {
+	if p.Burst == 0 {
+		p.Burst = p.Rate
+	}
+	if p.Algorithm == "" {
+		p.Algorithm = TokenBucket
+	}
+	if p.Cost == 0 {
+		p.Cost = 1
+	}
+	return p
+}
+
+// Validate reports whether the policy can be enforced. Defaults are applied
+// first, so omitted fields are not errors.
+func (p Policy) Validate() error {
+	p = p.withDefaults()
+
+	if p.Name == "" {
+		return errors.New("policy: name is required")
+	}
+	if p.Namespace == "" {
+		return fmt.Errorf("policy %q: namespace is required", p.Name)
+	}
+	if !p.Algorithm.valid() {
+		return fmt.Errorf("policy %q: unknown algorithm %q", p.Name, p.Algorithm)
+	}
+	if err := p.Limit().Validate(); err != nil {
+		return fmt.Errorf("policy %q: %w", p.Name, err)
+	}
+
+	capacity := p.Burst
+	if p.Algorithm.windowed() {
+		capacity = p.Rate
+	}
+	if p.Cost < 1 || p.Cost > capacity {
+		return fmt.Errorf("policy %q: cost %d must be between 1 and %d: %w", p.Name, p.Cost, capacity, limiter.ErrInvalidCost)
+	}
+	return nil
+}
+
+// Config is the layout of a policy file.
+type Config struct {
+	Policies []Policy `json:"policies"`
+}
+
+// Set is a validated collection of policies, indexed by namespace. It is
+// immutable once built.
+type Set struct {
+	byNamespace map[limiter.Namespace]Policy
+}
+
+// NewSet validates the policies, applies their defaults and indexes them by
+// namespace. Names and namespaces must be unique.
+func NewSet(policies []Policy) (*Set, error) {
+	s := &Set{byNamespace: make(map[limiter.Namespace]Policy, len(policies))}
+	names := make(map[string]bool, len(policies))
+
+	for _, p := range policies {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("policy %q: duplicate name", p.Name)
+		}
+		if _, exists := s.byNamespace[p.Namespace]; exists {
+			return nil, fmt.Errorf("policy %q: duplicate namespace %q", p.Name, p.Namespace)
+		}
+		names[p.Name] = true
+		s.byNamespace[p.Namespace] = p.withDefaults()
+	}
+	return s, nil
+}
+
+// Parse decodes and validates a policy file. Unknown fields are rejected, so
+// typos do not silently fall back to defaults.
+func Parse(data []byte) (*Set, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("policy: parse config: %w", err)
+	}
+	return NewSet(cfg.Policies)
+}
+
+// Load reads and parses the policy file at path.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Lookup returns the policy for a namespace.
+func (s *Set) Lookup(ns limiter.Namespace) (Policy, bool) {
+	p, ok := s.byNamespace[ns]
+	return p, ok
+}
+
+// Policies returns every policy in the set, in no particular order.
+func (s *Set) Policies() []Policy {
+	policies := make([]Policy, 0, len(s.byNamespace))
+	for _, p := range s.byNamespace {
+		policies = append(policies, p)
+	}
+	return policies
+}
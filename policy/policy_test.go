@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+func TestParse(t *testing.T) {
+	set, err := Parse([]byte(`{
+		"policies": [
+			{"name": "api", "namespace": "api_key", "rate": 100, "period": "1s", "burst": 200, "cost": 2},
+			{"name": "login", "namespace": "login", "rate": 5, "period": "15m", "algorithm": "sliding_log"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	api, ok := set.Lookup("api_key")
+	if !ok {
+		t.Fatal("Expected a policy for api_key")
+	}
+	want := limiter.Limit{Rate: 100, Period: time.Second, Burst: 200}
+	if api.Limit() != want || api.Cost != 2 || api.Algorithm != TokenBucket {
+		t.Errorf("Unexpected api policy: %+v", api)
+	}
+
+	login, _ := set.Lookup("login")
+	if login.Burst != 5 || login.Cost != 1 || login.Algorithm != SlidingLog {
+		t.Errorf("Expected defaults to be applied, got %+v", login)
+	}
+	if time.Duration(login.Period) != 15*time.Minute {
+		t.Errorf("Expected a 15m period, got %v", time.Duration(login.Period))
+	}
+
+	if _, ok := set.Lookup("unknown"); ok {
+		t.Error("Expected no policy for an unknown namespace")
+	}
+	if n := len(set.Policies()); n != 2 {
+		t.Errorf("Expected 2 policies, got %d", n)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"Syntax", `{"policies": [`, "parse config"},
+		{"UnknownField", `{"policies": [{"name": "a", "namespace": "a", "rate": 1, "period": "1s", "brust": 2}]}`, "unknown field"},
+		{"NumericPeriod", `{"policies": [{"name": "a", "namespace": "a", "rate": 1, "period": 1}]}`, "duration must be a string"},
+		{"MissingName", `{"policies": [{"namespace": "a", "rate": 1, "period": "1s"}]}`, "name is required"},
+		{"MissingNamespace", `{"policies": [{"name": "a", "rate": 1, "period": "1s"}]}`, "namespace is required"},
+		{"ZeroRate", `{"policies": [{"name": "a", "namespace": "a", "period": "1s"}]}`, "rate must be positive"},
+		{"MissingPeriod", `{"policies": [{"name": "a", "namespace": "a", "rate": 1}]}`, "period must be positive"},
+		{"UnknownAlgorithm", `{"policies": [{"name": "a", "namespace": "a", "rate": 1, "period": "1s", "algorithm": "leaky"}]}`, "unknown algorithm"},
+		{"CostExceedsBurst", `{"policies": [{"name": "a", "namespace": "a", "rate": 10, "period": "1s", "burst": 5, "cost": 6}]}`, "cost 6"},
+		{"DuplicateName", `{"policies": [
+			{"name": "a", "namespace": "a", "rate": 1, "period": "1s"},
+			{"name": "a", "namespace": "b", "rate": 1, "period": "1s"}]}`, "duplicate name"},
+		{"DuplicateNamespace", `{"policies": [
+			{"name": "a", "namespace": "a", "rate": 1, "period": "1s"},
+			{"name": "b", "namespace": "a", "rate": 1, "period": "1s"}]}`, "duplicate namespace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate_Sentinels(t *testing.T) {
+	p := Policy{Name: "a", Namespace: "a", Rate: 0, Period: Duration(time.Second)}
+	if err := p.Validate(); !errors.Is(err, limiter.ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+
+	// Window algorithms allow a cost of up to Rate, regardless of Burst.
+	p = Policy{Name: "a", Namespace: "a", Rate: 10, Period: Duration(time.Second), Burst: 1, Cost: 10, Algorithm: FixedWindow}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Expected a window policy with cost == rate to be valid, got %v", err)
+	}
+}
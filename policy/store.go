@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// Store serves the policies of a configuration file and reloads them when the
+// file changes. If a new version of the file fails to parse or validate, the
+// Store keeps serving the last good policies and reports the error.
+//
+// A Store is safe for concurrent use.
+type Store struct {
+	path     string
+	interval time.Duration
+	onError  func(error)
+
+	current atomic.Pointer[Set]
+
+	mu   sync.Mutex // serializes reloads
+	last []byte     // contents of the last file read, good or bad
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithInterval sets how often Watch checks the file for changes. The default
+// is 5 seconds; Open rejects an interval that is not positive.
+func WithInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.interval = d
+	}
+}
+
+// WithErrorHandler sets a function called when a changed file cannot be
+// loaded. By default such errors are ignored and the last good policies stay
+// in effect.
+func WithErrorHandler(fn func(error)) Option {
+	return func(s *Store) {
+		s.onError = fn
+	}
+}
+
+// Open loads the policy file at path. It fails if the file cannot be read or
+// is invalid, so a service never starts without policies.
+func Open(path string, opts ...Option) (*Store, error) {
+	s := &Store{
+		path:     path,
+		interval: 5 * time.Second,
+		onError:  func(error) {},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.interval <= 0 {
+		return nil, fmt.Errorf("policy: watch interval must be positive, got %v", s.interval)
+	}
+
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads the file and, if its contents changed since the last read,
+// replaces the current policies. It reports whether the policies changed. On
+// error the current policies are kept.
+func (s *Store) Reload() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	if s.last != nil && bytes.Equal(data, s.last) {
+		return false, nil
+	}
+	// Remember bad contents too, so the same error is reported only once.
+	s.last = data
+
+	set, err := Parse(data)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.current.Store(set)
+	return true, nil
+}
+
+// Watch checks the file for changes every interval until ctx is done,
+// reloading it as needed. It is typically run in its own goroutine.
+func (s *Store) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Reload(); err != nil {
+				s.onError(err)
+			}
+		}
+	}
+}
+
+// Policies returns the policy set currently in effect.
+func (s *Store) Policies() *Set {
+	return s.current.Load()
+}
+
+// Lookup returns the policy currently in effect for a namespace.
+func (s *Store) Lookup(ns limiter.Namespace) (Policy, bool) {
+	return s.Policies().Lookup(ns)
+}
+
+// Resolve returns the Limit of the policy for the identity's namespace, or
+// ErrNoPolicy. It can be used with any limiter.RateLimiter, for example
+// through limiter.NewPolicyLimiter.
+func (s *Store) Resolve(ctx context.Context, id limiter.Identity) (limiter.Limit, error) {
+	p, ok := s.Lookup(id.Namespace)
+	if !ok {
+		return limiter.Limit{}, fmt.Errorf("%w for namespace %q", ErrNoPolicy, id.Namespace)
+	}
+	return p.Limit(), nil
+}
+
+// ResolveCost returns the Limit and Cost of the policy for the identity's
+// namespace, or ErrNoPolicy. It makes the Store a limiter.CostResolver, so
+// PolicyLimiter.Allow charges the policy's cost.
+func (s *Store) ResolveCost(ctx context.Context, id limiter.Identity) (limiter.Limit, int64, error) {
+	p, ok := s.Lookup(id.Namespace)
+	if !ok {
+		return limiter.Limit{}, 0, fmt.Errorf("%w for namespace %q", ErrNoPolicy, id.Namespace)
+	}
+	return p.Limit(), p.Cost, nil
+}
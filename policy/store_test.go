@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+func writeConfig(t *testing.T, path, config string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	configV1 = `{"policies": [{"name": "api", "namespace": "api_key", "rate": 10, "period": "1s"}]}`
+	configV2 = `{"policies": [{"name": "api", "namespace": "api_key", "rate": 20, "period": "1s"}]}`
+)
+
+func TestStore_Resolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	writeConfig(t, path, configV1)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	ctx := context.Background()
+	limit, err := store.Resolve(ctx, limiter.Identity{Namespace: "api_key", Key: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (limiter.Limit{Rate: 10, Period: time.Second, Burst: 10}); limit != want {
+		t.Errorf("Expected %+v, got %+v", want, limit)
+	}
+
+	if _, err := store.Resolve(ctx, limiter.Identity{Namespace: "ip", Key: "1.2.3.4"}); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected ErrNoPolicy, got %v", err)
+	}
+
+	// The store plugs into any limiter through PolicyLimiter.
+	pl := limiter.NewPolicyLimiter(limiter.NewMemoryLimiter(), store)
+	if dec, err := pl.AllowN(ctx, limiter.Identity{Namespace: "api_key", Key: "k1"}, 10); err != nil || !dec.Allow {
+		t.Errorf("Expected the resolved burst of 10 to be allowed, got %+v (err %v)", dec, err)
+	}
+}
+
+func TestStore_ResolveCost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	writeConfig(t, path, `{"policies": [{"name": "export", "namespace": "export", "rate": 10, "period": "1m", "cost": 5}]}`)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	ctx := context.Background()
+	id := limiter.Identity{Namespace: "export", Key: "t1"}
+	if _, cost, err := store.ResolveCost(ctx, id); err != nil || cost != 5 {
+		t.Errorf("Expected cost 5, got %d (err %v)", cost, err)
+	}
+	if _, _, err := store.ResolveCost(ctx, limiter.Identity{Namespace: "ip"}); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected ErrNoPolicy, got %v", err)
+	}
+
+	pl := limiter.NewPolicyLimiter(limiter.NewMemoryLimiter(), store)
+	for i, want := range []bool{true, true, false} {
+		if dec, err := pl.Allow(ctx, id); err != nil || dec.Allow != want {
+			t.Errorf("Request %d: expected allowed=%v with a cost of 5 and burst of 10, got %+v (err %v)", i, want, dec, err)
+		}
+	}
+}
+
+func TestStore_OpenInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	writeConfig(t, path, `{"policies": [{"name": "api"}]}`)
+
+	if _, err := Open(path); err == nil {
+		t.Error("Expected Open to reject an invalid file")
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected Open to fail for a missing file")
+	}
+
+	writeConfig(t, path, configV1)
+	for _, d := range []time.Duration{0, -time.Second} {
+		if _, err := Open(path, WithInterval(d)); err == nil {
+			t.Errorf("Expected Open to reject a watch interval of %v", d)
+		}
+	}
+}
+
+func TestStore_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	writeConfig(t, path, configV1)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := store.Reload(); err != nil || changed {
+		t.Errorf("Expected no change for an untouched file, got %v (err %v)", changed, err)
+	}
+
+	writeConfig(t, path, configV2)
+	if changed, err := store.Reload(); err != nil || !changed {
+		t.Fatalf("Expected a change, got %v (err %v)", changed, err)
+	}
+	if p, _ := store.Lookup("api_key"); p.Rate != 20 {
+		t.Errorf("Expected the new rate, got %d", p.Rate)
+	}
+
+	// A bad edit keeps the last good policies in effect.
+	writeConfig(t, path, `{"policies": [{"name": "api", "namespace": "api_key", "rate": 0, "period": "1s"}]}`)
+	if _, err := store.Reload(); err == nil {
+		t.Error("Expected an error for an invalid file")
+	}
+	if p, _ := store.Lookup("api_key"); p.Rate != 20 {
+		t.Errorf("Expected the last good rate, got %d", p.Rate)
+	}
+}
+
+func TestStore_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	writeConfig(t, path, configV1)
+
+	errs := make(chan error, 1)
+	store, err := Open(path,
+		WithInterval(10*time.Millisecond),
+		WithErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx)
+
+	writeConfig(t, path, configV2)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if p, _ := store.Lookup("api_key"); p.Rate == 20 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected Watch to pick up the change")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	writeConfig(t, path, `not json`)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Expected a non-nil error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the error handler to be called")
+	}
+	if p, _ := store.Lookup("api_key"); p.Rate != 20 {
+		t.Errorf("Expected the last good rate, got %d", p.Rate)
+	}
+}
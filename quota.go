@@ -0,0 +1,116 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Interval is the calendar period after which a Quota resets.
+type Interval int
+
+const (
+	// Hourly quotas reset at the top of every hour.
+	Hourly Interval = iota + 1
+	// Daily quotas reset at midnight.
+	Daily
+	// Weekly quotas reset at midnight between Sunday and Monday.
+	Weekly
+	// Monthly quotas reset at midnight on the first day of the month.
+	Monthly
+)
+
+func (i Interval) String() string {
+	switch i {
+	case Hourly:
+		return "hourly"
+	case Daily:
+		return "daily"
+	case Weekly:
+		return "weekly"
+	case Monthly:
+		return "monthly"
+	}
+	return "Interval(" + strconv.Itoa(int(i)) + ")"
+}
+
+// Quota is a fixed allowance of Max events per calendar Interval, such as
+// "50,000 calls per month" or "1,000 calls per day". Unlike a token bucket it
+// does not refill gradually: the whole allowance becomes available again at
+// the start of the next interval.
+type Quota struct {
+	Max      int64
+	Interval Interval
+	// Location is the time zone whose calendar defines the boundaries, for
+	// example the customer's. A nil Location means UTC.
+	Location *time.Location
+}
+
+// Validate reports whether the quota can be enforced: Max must be positive
+// and Interval one of the defined constants. The error wraps ErrInvalidLimit.
+func (q Quota) Validate() error {
+	if q.Max <= 0 {
+		return fmt.Errorf("%w: quota max must be positive, got %d", ErrInvalidLimit, q.Max)
+	}
+	if q.Interval < Hourly || q.Interval > Monthly {
+		return fmt.Errorf("%w: unknown quota interval %v", ErrInvalidLimit, q.Interval)
+	}
+	return nil
+}
+
+// window returns the bounds of the calendar interval containing t.
+func (q Quota) window(t time.Time) (start, end time.Time) {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	year, month, day := t.Date()
+
+	switch q.Interval {
+	case Hourly:
+		// Subtract rather than rebuilding with time.Date, which would be
+		// ambiguous in the hour repeated when daylight saving time ends.
+		start = t.Add(-time.Duration(t.Minute())*time.Minute -
+			time.Duration(t.Second())*time.Second -
+			time.Duration(t.Nanosecond()))
+		return start, start.Add(time.Hour)
+	case Weekly:
+		day -= (int(t.Weekday()) + 6) % 7
+		start = time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return start, time.Date(year, month, day+7, 0, 0, 0, 0, loc)
+	case Monthly:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		return start, time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+	default:
+		start = time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return start, time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+	}
+}
+
+// QuotaLimiter enforces calendar-aligned quotas. Decisions always carry the
+// end of the current interval as ResetTime, whether or not the request was
+// allowed.
+type QuotaLimiter interface {
+	// Allow consumes one event from the identity's quota.
+	Allow(ctx context.Context, id Identity, quota Quota) (Decision, error)
+
+	// AllowN consumes n events if they all fit in the current interval. It
+	// returns ErrCostExceedsBurst if n is larger than quota.Max.
+	AllowN(ctx context.Context, id Identity, quota Quota, n int64) (Decision, error)
+}
+
+// quotaDecision builds the Decision for a quota whose current interval ends at
+// end, once used events (including any just admitted) have been counted.
+func quotaDecision(allowed bool, quota Quota, used int64, now, end time.Time) Decision {
+	dec := Decision{
+		Allow:     allowed,
+		Remaining: max(quota.Max-used, 0),
+		ResetTime: end,
+	}
+	if !allowed {
+		dec.RetryAfter = end.Sub(now)
+	}
+	return dec
+}
@@ -0,0 +1,153 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuotaWindow(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone database unavailable: %v", err)
+	}
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+
+	tests := []struct {
+		name       string
+		quota      Quota
+		at         time.Time
+		start, end time.Time
+	}{
+		{
+			name:  "HourlyHalfHourOffset",
+			quota: Quota{Interval: Hourly, Location: kolkata},
+			at:    time.Date(2024, 3, 5, 10, 45, 12, 0, kolkata),
+			start: time.Date(2024, 3, 5, 10, 0, 0, 0, kolkata),
+			end:   time.Date(2024, 3, 5, 11, 0, 0, 0, kolkata),
+		},
+		{
+			name:  "DailyInLocation",
+			quota: Quota{Interval: Daily, Location: newYork},
+			// 03:00 UTC is still the previous day in New York.
+			at:    time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC),
+			start: time.Date(2024, 3, 4, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 3, 5, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:  "DailyAcrossDSTStart",
+			quota: Quota{Interval: Daily, Location: newYork},
+			at:    time.Date(2024, 3, 10, 12, 0, 0, 0, newYork),
+			start: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			end:   time.Date(2024, 3, 11, 0, 0, 0, 0, newYork), // 23 hours later
+		},
+		{
+			name:  "WeeklyStartsMonday",
+			quota: Quota{Interval: Weekly},
+			at:    time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC), // a Sunday
+			start: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "MonthlyAcrossYear",
+			quota: Quota{Interval: Monthly},
+			at:    time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC),
+			start: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.quota.window(tt.at)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("Expected [%v, %v), got [%v, %v)", tt.start, tt.end, start, end)
+			}
+		})
+	}
+}
+
+func testQuota(t *testing.T, l QuotaLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	quota := Quota{Max: 3, Interval: Daily, Location: time.FixedZone("UTC-5", -5*3600)}
+	_, end := quota.window(time.Now())
+
+	t.Run("ExhaustsUntilIntervalEnd", func(t *testing.T) {
+		id := newID("plan")
+
+		dec, err := l.AllowN(ctx, id, quota, 2)
+		if err != nil || !dec.Allow || dec.Remaining != 1 {
+			t.Fatalf("Expected 2 calls allowed with 1 remaining, got %+v (err %v)", dec, err)
+		}
+		if !dec.ResetTime.Equal(end) {
+			t.Errorf("Expected ResetTime %v, got %v", end, dec.ResetTime)
+		}
+
+		if dec, err := l.Allow(ctx, id, quota); err != nil || !dec.Allow || dec.Remaining != 0 {
+			t.Fatalf("Expected last call allowed, got %+v (err %v)", dec, err)
+		}
+
+		before := time.Now()
+		dec, err = l.Allow(ctx, id, quota)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Fatal("Expected the exhausted quota to deny")
+		}
+		if !dec.ResetTime.Equal(end) {
+			t.Errorf("Expected ResetTime %v, got %v", end, dec.ResetTime)
+		}
+		if dec.RetryAfter > end.Sub(before) || dec.RetryAfter < time.Until(end) {
+			t.Errorf("Expected RetryAfter until %v, got %v", end, dec.RetryAfter)
+		}
+	})
+
+	t.Run("IntervalsCountSeparately", func(t *testing.T) {
+		id := newID("plan-multi")
+		monthly := Quota{Max: 3, Interval: Monthly}
+
+		if _, err := l.AllowN(ctx, id, quota, 3); err != nil {
+			t.Fatal(err)
+		}
+		if dec, err := l.Allow(ctx, id, monthly); err != nil || !dec.Allow {
+			t.Errorf("Expected the monthly quota to be untouched, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("LocationsCountSeparately", func(t *testing.T) {
+		id := newID("plan-zones")
+		west := Quota{Max: 3, Interval: Daily, Location: time.FixedZone("UTC-12", -12*3600)}
+		east := Quota{Max: 3, Interval: Daily, Location: time.FixedZone("UTC+14", 14*3600)}
+
+		if _, err := l.AllowN(ctx, id, west, 3); err != nil {
+			t.Fatal(err)
+		}
+		if dec, err := l.Allow(ctx, id, east); err != nil || !dec.Allow {
+			t.Errorf("Expected a day starting at another instant to be untouched, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("CostExceedsQuota", func(t *testing.T) {
+		if _, err := l.AllowN(ctx, newID("bulk"), quota, 4); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+		}
+	})
+}
+
+func TestMemoryQuotaLimiter(t *testing.T) {
+	testQuota(t, NewMemoryQuotaLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisQuotaLimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisQuotaLimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisQuotaLimiter: %v", err)
+	}
+	testQuota(t, limiter, uniqueIdentity)
+}
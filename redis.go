@@ -4,84 +4,230 @@ import (
 	"context"
 	_ "embed"
 	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-//go:embed token_bucket.lua
-var tokenBucketScript string
+var (
+	//go:embed token_bucket.lua
+	tokenBucketSource string
+
+	//go:embed token_bucket_reserve.lua
+	reserveSource string
+
+	//go:embed token_bucket_adjust.lua
+	adjustSource string
+
+	//go:embed token_bucket_peek.lua
+	peekSource string
+
+	//go:embed token_bucket_multi.lua
+	multiSource string
+
+	// overrideSource is a prelude prepended to every token bucket script, so
+	// per-identity overrides are applied inside the same atomic call.
+	//go:embed override.lua
+	overrideSource string
+
+	tokenBucketScript = redis.NewScript(overrideSource + tokenBucketSource)
+	reserveScript     = redis.NewScript(overrideSource + reserveSource)
+	adjustScript      = redis.NewScript(overrideSource + adjustSource)
+	peekScript        = redis.NewScript(overrideSource + peekSource)
+	multiScript       = redis.NewScript(overrideSource + multiSource)
+
+	// tokenBucketScripts lists every script NewRedisLimiter loads into Redis.
+	tokenBucketScripts = []*redis.Script{tokenBucketScript, reserveScript, adjustScript, peekScript, multiScript}
+)
 
 // RedisLimiter is a distributed rate limiter backed by Redis.
 //
 // It uses a Lua script to perform the token-bucket update atomically, which
 // allows multiple application instances to enforce a single shared limit.
 type RedisLimiter struct {
-	client    *redis.Client
-	scriptSHA string
-	recorder  MetricsRecorder
-	prefix    string
-	timeout   time.Duration
+	client *redis.Client
+	redisConfig
+}
+
+// redisConfig holds the settings shared by every Redis-backed limiter in this
+// package.
+type redisConfig struct {
+	recorder MetricsRecorder
+	prefix   string
+	timeout  time.Duration
 }
 
-// Option configures a RedisLimiter.
-type Option func(*RedisLimiter)
+// Option configures a Redis-backed limiter.
+type Option func(*redisConfig)
 
 // WithPrefix sets the Redis key prefix. Default is "limiter:".
 func WithPrefix(prefix string) Option {
-	return func(r *RedisLimiter) {
-		r.prefix = prefix
+	return func(c *redisConfig) {
+		c.prefix = prefix
 	}
 }
 
 // WithTimeout sets the timeout for Redis operations during initialization. Default is 5s.
 func WithTimeout(timeout time.Duration) Option {
-	return func(r *RedisLimiter) {
-		r.timeout = timeout
+	return func(c *redisConfig) {
+		c.timeout = timeout
 	}
 }
 
 // WithRecorder sets the metrics recorder. Default is NoOpMetricsRecorder.
 func WithRecorder(recorder MetricsRecorder) Option {
-	return func(r *RedisLimiter) {
-		r.recorder = recorder
+	return func(c *redisConfig) {
+		c.recorder = recorder
 	}
 }
 
-// NewRedisLimiter validates connectivity and loads the embedded Lua script into
-// Redis (SCRIPT LOAD). The returned limiter is ready to use.
-func NewRedisLimiter(client *redis.Client, opts ...Option) (*RedisLimiter, error) {
-	limiter := &RedisLimiter{
-		client:   client,
+func newRedisConfig(opts []Option) redisConfig {
+	cfg := redisConfig{
 		prefix:   "limiter:",
 		timeout:  5 * time.Second,
 		recorder: &NoOpMetricsRecorder{},
 	}
 
 	for _, opt := range opts {
-		opt(limiter)
+		opt(&cfg)
 	}
+	return cfg
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), limiter.timeout)
+// connect validates connectivity and loads the given scripts in a single
+// pipelined round trip, bounded by the configured timeout.
+func (c redisConfig) connect(client *redis.Client, scripts ...*redis.Script) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, err
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Ping(ctx)
+		for _, script := range scripts {
+			script.Load(ctx, pipe)
+		}
+		return nil
+	})
+	if err != nil {
+		return backendError(err)
 	}
+	return nil
+}
 
-	sha, err := client.ScriptLoad(ctx, tokenBucketScript).Result()
-	if err != nil {
+// Kinds of state kept under keyFor, one per limiter other than the token
+// bucket, plus the token bucket's overrides.
+const (
+	concurrencyKind = "concurrency"
+	creditsKind     = "credits"
+	fixedKind       = "fixed"
+	gcraKind        = "gcra"
+	logKind         = "log"
+	overrideKind    = "override"
+	quotaKind       = "quota"
+	slidingKind     = "sliding"
+)
+
+var keyKinds = []string{
+	concurrencyKind, creditsKind, fixedKind, gcraKind,
+	logKind, overrideKind, quotaKind, slidingKind,
+}
+
+// keyFor builds the Redis key for state of the given kind kept by limiters
+// other than the token bucket: "{prefix}{kind}:{namespace}:{key}".
+func (c redisConfig) keyFor(kind string, id Identity) string {
+	return c.prefix + kind + ":" + string(id.Namespace) + ":" + id.Key
+}
+
+// checkNamespace rejects the namespaces whose token bucket keys,
+// "{prefix}{namespace}:{key}", can equal a keyFor key under the same prefix:
+// those whose first colon-separated segment is one of keyKinds.
+func checkNamespace(id Identity) error {
+	first, _, _ := strings.Cut(string(id.Namespace), ":")
+	if slices.Contains(keyKinds, first) {
+		return fmt.Errorf("%w: %q is used by %s keys", ErrReservedNamespace, id.Namespace, first)
+	}
+	return nil
+}
+
+func (c redisConfig) recordError(id Identity, kind string) {
+	c.recorder.Add("ratelimit.errors", 1, map[string]string{
+		"namespace": string(id.Namespace),
+		"type":      kind,
+	})
+}
+
+func (c redisConfig) recordDecision(id Identity, dec Decision) {
+	status := "denied"
+	if dec.Allow {
+		status = "allowed"
+	}
+	c.recorder.Add("ratelimit.call", 1, map[string]string{
+		"namespace": string(id.Namespace),
+		"status":    status,
+	})
+}
+
+// Prefixes of the error replies of Lua scripts: invalidLimitCode when they
+// reject their arguments, costExceedsBurstCode when the cost is larger than
+// the burst of an identity's override.
+const (
+	invalidLimitCode     = "INVALIDLIMIT "
+	costExceedsBurstCode = "COSTEXCEEDSBURST "
+)
+
+// backendError classifies an error returned by a Redis command. A script
+// rejecting its arguments maps to ErrInvalidLimit, and a cost larger than an
+// override's burst to ErrCostExceedsBurst; anything else wraps both
+// ErrBackendUnavailable and the original error, so callers can still match
+// context.Canceled or context.DeadlineExceeded.
+func backendError(err error) error {
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		if msg, ok := strings.CutPrefix(redisErr.Error(), invalidLimitCode); ok {
+			return fmt.Errorf("%w: %s", ErrInvalidLimit, msg)
+		}
+		if msg, ok := strings.CutPrefix(redisErr.Error(), costExceedsBurstCode); ok {
+			return fmt.Errorf("%w: %s", ErrCostExceedsBurst, msg)
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrBackendUnavailable, err)
+}
+
+// NewRedisLimiter validates connectivity and loads the embedded Lua scripts into
+// Redis (SCRIPT LOAD). The returned limiter is ready to use.
+func NewRedisLimiter(client *redis.Client, opts ...Option) (*RedisLimiter, error) {
+	limiter := &RedisLimiter{
+		client:      client,
+		redisConfig: newRedisConfig(opts),
+	}
+
+	if err := limiter.connect(client, tokenBucketScripts...); err != nil {
 		return nil, err
 	}
 
-	limiter.scriptSHA = sha
 	return limiter, nil
 }
 
 // Allow checks whether a request for the given identity should be allowed under
 // the provided limit. Each call has a fixed cost of 1 token.
 func (r *RedisLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return r.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN checks whether a request costing n tokens should be allowed under the
+// provided limit. The Lua script only deducts the tokens if all n are
+// available, so a partially funded request never consumes anything.
+func (r *RedisLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+	if err := checkNamespace(id); err != nil {
+		return Decision{}, err
+	}
+
 	// 0. Instrumentation Setup
 	start := time.Now()
 	status := "error" // Default status if we fail before decision
@@ -94,45 +240,23 @@ func (r *RedisLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Dec
 		})
 	}()
 
-	// 1. Prepare Inputs
-	key := r.prefix + string(id.Namespace) + ":" + id.Key
-	now := float64(time.Now().UnixMicro()) / 1e6
-	cost := 1.0
-	ratePerSecond := float64(limit.Rate) / limit.Period.Seconds()
-
-	cmd := r.client.EvalSha(ctx, r.scriptSHA, []string{key},
-		ratePerSecond, // ARGV[1]
-		limit.Burst,   // ARGV[2]
-		now,           // ARGV[3]
-		cost,          // ARGV[4]
-	)
+	// 1. Run the token bucket script
+	cmd := r.evalTokenBucket(ctx, r.client, id, limit, n, time.Now())
 
 	result, err := cmd.Result()
 	if err != nil {
 		// Record the error explicitly
-		r.recorder.Add("ratelimit.errors", 1, map[string]string{
-			"namespace": string(id.Namespace),
-			"type":      "redis_eval",
-		})
-		return Decision{}, err
+		r.recordError(id, "redis_eval")
+		return Decision{}, backendError(err)
 	}
 
-	values, ok := result.([]interface{})
-	if !ok || len(values) != 4 {
-		r.recorder.Add("ratelimit.errors", 1, map[string]string{
-			"namespace": string(id.Namespace),
-			"type":      "invalid_format",
-		})
-		return Decision{}, errors.New("invalid lua response format")
+	dec, err := parseDecision(result)
+	if err != nil {
+		r.recordError(id, "invalid_format")
+		return Decision{}, err
 	}
 
-	allowedVal := int64(convertToFloat(values[0]))
-	remainingVal := int64(convertToFloat(values[1]))
-
-	retryAfterFloat := convertToFloat(values[2])
-	resetTimeFloat := convertToFloat(values[3])
-
-	if allowedVal == 1 {
+	if dec.Allow {
 		status = "allowed"
 	} else {
 		status = "denied"
@@ -143,14 +267,376 @@ func (r *RedisLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Dec
 		"status":    status,
 	})
 
+	return dec, nil
+}
+
+// AllowMany evaluates each check independently, pipelining one EVALSHA per
+// check so the whole batch costs a single round trip. Each Result holds
+// either a Decision or the error for that check. Every pipelined check records
+// one ratelimit.latency observation of the shared round trip, tagged with its
+// own namespace and status.
+func (r *RedisLimiter) AllowMany(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, len(checks))
+	cmds := make([]*redis.Cmd, len(checks))
+
+	now := time.Now()
+	pipe := r.client.Pipeline()
+	for i, c := range checks {
+		if err := checkCost(c.Limit, c.cost()); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := checkNamespace(c.ID); err != nil {
+			results[i].Err = err
+			continue
+		}
+		cmds[i] = r.evalTokenBucket(ctx, pipe, c.ID, c.Limit, c.cost(), now)
+	}
+
+	// Exec reports the first failure; every command carries its own error,
+	// which is what the caller needs.
+	start := time.Now()
+	_, _ = pipe.Exec(ctx)
+	elapsed := time.Since(start).Seconds()
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		id := checks[i].ID
+		results[i] = r.batchResult(id, cmd)
+
+		status := "error"
+		if results[i].Err == nil {
+			status = "denied"
+			if results[i].Decision.Allow {
+				status = "allowed"
+			}
+		}
+		r.recorder.Observe("ratelimit.latency", elapsed, map[string]string{
+			"namespace": string(id.Namespace),
+			"status":    status,
+		})
+	}
+
+	return results
+}
+
+// batchResult parses the reply to one pipelined check of AllowMany.
+func (r *RedisLimiter) batchResult(id Identity, cmd *redis.Cmd) Result {
+	result, err := cmd.Result()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return Result{Err: backendError(err)}
+	}
+
+	dec, err := parseDecision(result)
+	if err != nil {
+		r.recordError(id, "invalid_format")
+		return Result{Err: err}
+	}
+
+	r.recordDecision(id, dec)
+	return Result{Decision: dec}
+}
+
+// AllowAll evaluates every check in a single multi-key Lua script and only
+// deducts tokens if all of them admit the request, so a partially admitted
+// request never consumes anything. With Redis Cluster, all keys must hash to
+// the same slot (use a hash tag in the prefix or keys).
+func (r *RedisLimiter) AllowAll(ctx context.Context, checks []Check) (MultiDecision, error) {
+	return r.allowChecks(ctx, checks, false)
+}
+
+// AllowHierarchy evaluates every level of h in a single multi-key Lua script,
+// including any borrowing, and only deducts tokens if the whole chain admits
+// the request. The same Redis Cluster caveat as AllowAll applies.
+func (r *RedisLimiter) AllowHierarchy(ctx context.Context, h Hierarchy) (MultiDecision, error) {
+	return r.allowChecks(ctx, h.Levels, h.Borrow)
+}
+
+func (r *RedisLimiter) allowChecks(ctx context.Context, checks []Check, borrow bool) (MultiDecision, error) {
+	if err := validateChecks(checks); err != nil {
+		return MultiDecision{}, err
+	}
+	for _, c := range checks {
+		if err := checkNamespace(c.ID); err != nil {
+			return MultiDecision{}, err
+		}
+	}
+
+	now := time.Now()
+	borrowFlag := 0
+	if borrow {
+		borrowFlag = 1
+	}
+
+	n := len(checks)
+	keys := make([]string, 2*n)
+	args := make([]interface{}, 0, 2+3*n)
+	args = append(args, unixSeconds(now), borrowFlag)
+	for i, c := range checks {
+		keys[i] = r.key(c.ID)
+		keys[n+i] = r.overrideKey(c.ID)
+		args = append(args, ratePerSecond(c.Limit), c.Limit.Burst, float64(c.cost()))
+	}
+
+	result, err := multiScript.EvalSha(ctx, r.client, keys, args...).Result()
+	if err != nil {
+		r.recordError(checks[0].ID, "redis_eval")
+		return MultiDecision{}, backendError(err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2+5*n {
+		r.recordError(checks[0].ID, "invalid_format")
+		return MultiDecision{}, scriptResponseError(result)
+	}
+
+	o := outcome{
+		deductions: make([]float64, n),
+		short:      int(convertToFloat(values[0])),
+		need:       convertToFloat(values[1]),
+	}
+	balances := make([]float64, n)
+	applied := make([]Check, n)
+	for i, c := range checks {
+		balances[i] = convertToFloat(values[2+i])
+		o.deductions[i] = convertToFloat(values[2+n+i])
+
+		// Derive decisions under the limit the script applied.
+		applied[i] = c
+		if limit := parseOverride(values[2+2*n+3*i : 2+2*n+3*i+3]); limit.Rate > 0 {
+			applied[i].Limit = limit
+		}
+	}
+	md := decide(applied, balances, o, borrow, now)
+
+	status := "denied"
+	if md.Allow {
+		status = "allowed"
+	}
+	for _, c := range checks {
+		r.recorder.Add("ratelimit.call", 1, map[string]string{
+			"namespace": string(c.ID.Namespace),
+			"status":    status,
+		})
+	}
+
+	return md, nil
+}
+
+// Reserve is shorthand for ReserveN(ctx, id, limit, 1).
+func (r *RedisLimiter) Reserve(ctx context.Context, id Identity, limit Limit) (*Reservation, error) {
+	return r.ReserveN(ctx, id, limit, 1)
+}
+
+// ReserveN deducts n tokens now, letting the bucket go into debt if needed, and
+// returns a Reservation telling the caller when it may act. Cancelling the
+// reservation returns the tokens with an atomic Lua refund.
+func (r *RedisLimiter) ReserveN(ctx context.Context, id Identity, limit Limit, n int64) (*Reservation, error) {
+	return r.reserveN(ctx, id, limit, n, noMaxWait)
+}
+
+// Wait is shorthand for WaitN(ctx, id, limit, 1).
+func (r *RedisLimiter) Wait(ctx context.Context, id Identity, limit Limit) error {
+	return r.WaitN(ctx, id, limit, 1)
+}
+
+// WaitN blocks until n tokens are available or ctx is done. It fails fast with
+// ErrWaitExceedsDeadline if the wait would outlast the context deadline; the
+// check and the deduction happen in the same script, so a refused caller
+// never takes tokens from anyone else.
+func (r *RedisLimiter) WaitN(ctx context.Context, id Identity, limit Limit, n int64) error {
+	return waitN(ctx, func(maxWait time.Duration) (*Reservation, error) {
+		return r.reserveN(ctx, id, limit, n, maxWait)
+	})
+}
+
+func (r *RedisLimiter) reserveN(ctx context.Context, id Identity, limit Limit, n int64, maxWait time.Duration) (*Reservation, error) {
+	if err := checkCost(limit, n); err != nil {
+		return nil, err
+	}
+	if err := checkNamespace(id); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := reserveScript.EvalSha(ctx, r.client, r.keys(id),
+		ratePerSecond(limit), // ARGV[1]
+		limit.Burst,          // ARGV[2]
+		unixSeconds(now),     // ARGV[3]
+		float64(n),           // ARGV[4]
+		maxWait.Seconds(),    // ARGV[5]
+	).Result()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return nil, backendError(err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		r.recordError(id, "invalid_format")
+		return nil, scriptResponseError(result)
+	}
+
+	wait := time.Duration(convertToFloat(values[1]) * float64(time.Second))
+	if convertToFloat(values[0]) != 1 {
+		return nil, waitTooLong(wait, maxWait)
+	}
+
+	return newReservation(n, now.Add(wait), func(ctx context.Context) error {
+		return r.adjust(ctx, id, limit, float64(n), 0)
+	}), nil
+}
+
+// Peek reports the identity's current bucket without consuming tokens. It runs
+// a read-only script, so it never extends the key's expiry either.
+func (r *RedisLimiter) Peek(ctx context.Context, id Identity, limit Limit) (BucketState, error) {
+	if err := limit.Validate(); err != nil {
+		return BucketState{}, err
+	}
+	if err := checkNamespace(id); err != nil {
+		return BucketState{}, err
+	}
+
+	now := time.Now()
+	result, err := peekScript.EvalSha(ctx, r.client, r.keys(id),
+		ratePerSecond(limit), // ARGV[1]
+		limit.Burst,          // ARGV[2]
+		unixSeconds(now),     // ARGV[3]
+	).Result()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return BucketState{}, backendError(err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3 {
+		r.recordError(id, "invalid_format")
+		return BucketState{}, scriptResponseError(result)
+	}
+
+	tokens := convertToFloat(values[0])
+	return BucketState{
+		Tokens:      tokens,
+		Remaining:   wholeTokens(tokens),
+		TimeToFull:  time.Duration(convertToFloat(values[1]) * float64(time.Second)),
+		NextTokenAt: now.Add(time.Duration(convertToFloat(values[2]) * float64(time.Second))),
+	}, nil
+}
+
+// evalTokenBucket runs token_bucket.lua for one identity on c, which is either
+// the client or a pipeline.
+func (r *RedisLimiter) evalTokenBucket(ctx context.Context, c redis.Scripter, id Identity, limit Limit, n int64, now time.Time) *redis.Cmd {
+	return tokenBucketScript.EvalSha(ctx, c, r.keys(id),
+		ratePerSecond(limit), // ARGV[1]
+		limit.Burst,          // ARGV[2]
+		unixSeconds(now),     // ARGV[3]
+		float64(n),           // ARGV[4]
+	)
+}
+
+// Reset deletes the identity's bucket.
+func (r *RedisLimiter) Reset(ctx context.Context, id Identity) error {
+	if err := checkNamespace(id); err != nil {
+		return err
+	}
+	if err := r.client.Del(ctx, r.key(id)).Err(); err != nil {
+		r.recordError(id, "redis_del")
+		return backendError(err)
+	}
+	return nil
+}
+
+// Refund atomically returns n tokens to the identity's bucket, capped at
+// limit.Burst.
+func (r *RedisLimiter) Refund(ctx context.Context, id Identity, limit Limit, n int64) error {
+	if err := checkRefund(limit, n); err != nil {
+		return err
+	}
+	return r.adjust(ctx, id, limit, float64(n), 0)
+}
+
+// Authorize deducts estimate tokens like AllowN and, if they were available,
+// returns an Authorization to settle with the actual cost. Settlement runs in
+// a single Lua script, so concurrent requests never see a partial update.
+func (r *RedisLimiter) Authorize(ctx context.Context, id Identity, limit Limit, estimate int64) (Decision, *Authorization, error) {
+	dec, err := r.AllowN(ctx, id, limit, estimate)
+	if err != nil || !dec.Allow {
+		return dec, nil, err
+	}
+
+	return dec, newAuthorization(estimate, func(ctx context.Context, amount, maxDebt int64) error {
+		return r.adjust(ctx, id, limit, float64(amount), maxDebt)
+	}), nil
+}
+
+// adjust atomically adds amount tokens to the identity's bucket, capped at
+// limit.Burst. A negative amount charges the bucket, taking it into debt of at
+// most maxDebt tokens.
+func (r *RedisLimiter) adjust(ctx context.Context, id Identity, limit Limit, amount float64, maxDebt int64) error {
+	if err := checkNamespace(id); err != nil {
+		return err
+	}
+	err := adjustScript.EvalSha(ctx, r.client, r.keys(id),
+		ratePerSecond(limit),    // ARGV[1]
+		limit.Burst,             // ARGV[2]
+		unixSeconds(time.Now()), // ARGV[3]
+		amount,                  // ARGV[4]
+		-maxDebt,                // ARGV[5]
+	).Err()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return backendError(err)
+	}
+	return nil
+}
+
+// key builds the Redis key for an identity: "{prefix}{namespace}:{key}".
+func (r *RedisLimiter) key(id Identity) string {
+	return r.prefix + string(id.Namespace) + ":" + id.Key
+}
+
+// keys returns the KEYS of the token bucket scripts for an identity: its
+// bucket and its override.
+func (r *RedisLimiter) keys(id Identity) []string {
+	return []string{r.key(id), r.overrideKey(id)}
+}
+
+// parseDecision converts the {allowed, remaining, retry_after, reset_time}
+// reply of token_bucket.lua into a Decision.
+func parseDecision(result interface{}) (Decision, error) {
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 4 {
+		return Decision{}, scriptResponseError(result)
+	}
+
+	allowedVal := int64(convertToFloat(values[0]))
+	remainingVal := int64(convertToFloat(values[1]))
+
+	retryAfterFloat := convertToFloat(values[2])
+	resetTimeFloat := convertToFloat(values[3])
+
 	return Decision{
 		Allow:      allowedVal == 1,
-		Remaining:  remainingVal,
+		Remaining:  max(remainingVal, 0),
 		RetryAfter: time.Duration(retryAfterFloat * float64(time.Second)),
 		ResetTime:  time.UnixMicro(int64(resetTimeFloat * 1e6)),
 	}, nil
 }
 
+// ratePerSecond converts a Limit into the refill rate the Lua scripts expect.
+func ratePerSecond(limit Limit) float64 {
+	return float64(limit.Rate) / limit.Period.Seconds()
+}
+
+// unixSeconds converts t into fractional seconds since the epoch with
+// microsecond precision, the clock format used by the Lua scripts.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
 func convertToFloat(val interface{}) float64 {
 	switch v := val.(type) {
 	case int64:
@@ -0,0 +1,154 @@
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SetOverride replaces the identity's limit with limit for ttl, or until
+// deleted if ttl is 0. It replaces any existing override.
+//
+// The override is a hash under "{prefix}override:{namespace}:{key}", which
+// also holds the identity for ListOverrides, that the token bucket scripts read in the same call that updates the bucket. With
+// Redis Cluster, an identity's bucket and override keys must hash to the same
+// slot (use a hash tag in the key).
+func (r *RedisLimiter) SetOverride(ctx context.Context, id Identity, limit Limit, ttl time.Duration) error {
+	if err := checkOverride(limit, ttl); err != nil {
+		return err
+	}
+
+	key := r.overrideKey(id)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			"rate", limit.Rate,
+			"period", int64(limit.Period),
+			"burst", limit.Burst,
+			"namespace", string(id.Namespace),
+			"key", id.Key,
+		)
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		r.recordError(id, "redis_hset")
+		return backendError(err)
+	}
+	return nil
+}
+
+// GetOverride returns the identity's override, if it has one.
+func (r *RedisLimiter) GetOverride(ctx context.Context, id Identity) (Override, bool, error) {
+	return r.loadOverride(ctx, r.overrideKey(id), id)
+}
+
+// loadOverride reads the override hash under key. id tags the error metric.
+func (r *RedisLimiter) loadOverride(ctx context.Context, key string, id Identity) (Override, bool, error) {
+	var (
+		fields *redis.SliceCmd
+		ttl    *redis.DurationCmd
+	)
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HMGet(ctx, key, "rate", "period", "burst", "namespace", "key")
+		ttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil {
+		r.recordError(id, "redis_hmget")
+		return Override{}, false, backendError(err)
+	}
+
+	values := fields.Val()
+	limit := parseOverride(values[:3])
+	if limit.Rate <= 0 {
+		return Override{}, false, nil
+	}
+
+	o := Override{
+		ID:    Identity{Namespace: Namespace(toString(values[3])), Key: toString(values[4])},
+		Limit: limit,
+	}
+	if d := ttl.Val(); d > 0 {
+		o.ExpiresAt = time.Now().Add(d)
+	}
+	return o, true, nil
+}
+
+// DeleteOverride removes the identity's override, if any.
+func (r *RedisLimiter) DeleteOverride(ctx context.Context, id Identity) error {
+	if err := r.client.Del(ctx, r.overrideKey(id)).Err(); err != nil {
+		r.recordError(id, "redis_del")
+		return backendError(err)
+	}
+	return nil
+}
+
+// ListOverrides returns the overrides in a namespace, or in every namespace if
+// ns is empty. It walks the keyspace with SCAN, so it is meant for admin
+// tooling rather than the request path. Identities are read from the override
+// hashes, since a key does not tell where a namespace containing ':' ends.
+func (r *RedisLimiter) ListOverrides(ctx context.Context, ns Namespace) ([]Override, error) {
+	base := r.prefix + overrideKind + ":"
+	match := globEscaper.Replace(base) + "*"
+	if ns != "" {
+		match = globEscaper.Replace(base+string(ns)+":") + "*"
+	}
+
+	var list []Override
+	iter := r.client.Scan(ctx, 0, match, 100).Iterator()
+	for iter.Next(ctx) {
+		o, found, err := r.loadOverride(ctx, iter.Val(), Identity{Namespace: ns})
+		if err != nil {
+			return nil, err
+		}
+		// The pattern for ns also matches namespaces that start with "ns:".
+		if found && (ns == "" || o.ID.Namespace == ns) {
+			list = append(list, o)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		r.recordError(Identity{Namespace: ns}, "redis_scan")
+		return nil, backendError(err)
+	}
+	return list, nil
+}
+
+// overrideKey builds the Redis key of an identity's override.
+func (r *RedisLimiter) overrideKey(id Identity) string {
+	return r.keyFor(overrideKind, id)
+}
+
+// parseOverride converts the rate, period and burst fields of an override into
+// a Limit. Missing fields yield a zero Limit.
+func parseOverride(values []interface{}) Limit {
+	if len(values) != 3 {
+		return Limit{}
+	}
+	period, _ := strconv.ParseInt(toString(values[1]), 10, 64)
+	return Limit{
+		Rate:   int64(convertToFloat(values[0])),
+		Period: time.Duration(period),
+		Burst:  int64(convertToFloat(values[2])),
+	}
+}
+
+// toString returns a Redis reply value as a string, or "" if it is nil.
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+// globEscaper escapes the characters SCAN MATCH treats as patterns.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
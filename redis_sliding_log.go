@@ -0,0 +1,77 @@
+package limiter
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed sliding_log.lua
+	slidingLogSource string
+
+	slidingLogScript = redis.NewScript(slidingLogSource)
+)
+
+// RedisSlidingLogLimiter is a distributed RateLimiter implementing the sliding
+// window log algorithm on Redis. It allows at most limit.Rate events in any
+// rolling limit.Period; Burst is not used.
+//
+// Each identity's events are stored in a sorted set scored by time, under
+// "{prefix}log:{namespace}:{key}", and trimmed atomically by a Lua script on
+// every call. Memory use is proportional to Rate per identity.
+type RedisSlidingLogLimiter struct {
+	client *redis.Client
+	redisConfig
+}
+
+// NewRedisSlidingLogLimiter validates connectivity and loads the embedded Lua
+// script. It accepts the same options as NewRedisLimiter.
+func NewRedisSlidingLogLimiter(client *redis.Client, opts ...Option) (*RedisSlidingLogLimiter, error) {
+	limiter := &RedisSlidingLogLimiter{
+		client:      client,
+		redisConfig: newRedisConfig(opts),
+	}
+
+	if err := limiter.connect(client, slidingLogScript); err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// Allow records one event if fewer than limit.Rate happened in the last
+// limit.Period.
+func (r *RedisSlidingLogLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return r.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN records n events if they all fit in the current window.
+func (r *RedisSlidingLogLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkWindowCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	result, err := slidingLogScript.EvalSha(ctx, r.client, []string{r.keyFor(logKind, id)},
+		time.Now().UnixMicro(),      // ARGV[1]
+		limit.Period.Microseconds(), // ARGV[2]
+		limit.Rate,                  // ARGV[3]
+		n,                           // ARGV[4]
+		uniqueToken(),               // ARGV[5]
+	).Result()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return Decision{}, backendError(err)
+	}
+
+	dec, err := parseDecision(result)
+	if err != nil {
+		r.recordError(id, "invalid_format")
+		return Decision{}, err
+	}
+
+	r.recordDecision(id, dec)
+	return dec, nil
+}
@@ -2,6 +2,7 @@ package limiter
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -67,6 +68,35 @@ func TestRedisLimiter_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("AllowN", func(t *testing.T) {
+		key := fmt.Sprintf("allown_test_%d", time.Now().UnixNano())
+		id := Identity{Namespace: "integration", Key: key}
+		limit := Limit{Rate: 10, Period: time.Second, Burst: 10}
+
+		dec, err := limiter.AllowN(ctx, id, limit, 7)
+		if err != nil {
+			t.Fatalf("Redis error: %v", err)
+		}
+		if !dec.Allow || dec.Remaining != 3 {
+			t.Fatalf("Expected allow with 3 remaining, got %+v", dec)
+		}
+
+		dec, err = limiter.AllowN(ctx, id, limit, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Error("Expected cost 5 to be denied with only 3 tokens left")
+		}
+		if dec.RetryAfter < 150*time.Millisecond || dec.RetryAfter > 200*time.Millisecond {
+			t.Errorf("Expected RetryAfter of ~200ms for 2 missing tokens, got %v", dec.RetryAfter)
+		}
+
+		if _, err := limiter.AllowN(ctx, id, limit, 11); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+		}
+	})
+
 	t.Run("DistributedState", func(t *testing.T) {
 		key := fmt.Sprintf("dist_test_%d", time.Now().UnixNano())
 		id := Identity{Namespace: "integration", Key: key}
@@ -86,3 +116,30 @@ func TestRedisLimiter_Integration(t *testing.T) {
 		}
 	})
 }
+
+// newIntegrationLimiter connects to the local Redis used by the integration
+// tests and skips the test if it is not reachable.
+func newIntegrationLimiter(t *testing.T, opts ...Option) (*RedisLimiter, *redis.Client) {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	t.Cleanup(func() { client.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available (%v)", err)
+	}
+
+	limiter, err := NewRedisLimiter(client, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create RedisLimiter: %v", err)
+	}
+	return limiter, client
+}
+
+// uniqueIdentity returns an identity that no other test run has used.
+func uniqueIdentity(prefix string) Identity {
+	return Identity{Namespace: "integration", Key: fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())}
+}
@@ -0,0 +1,157 @@
+package limiter
+
+import (
+	"context"
+	_ "embed"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed fixed_window.lua
+	fixedWindowSource string
+	//go:embed sliding_window.lua
+	slidingWindowSource string
+
+	fixedWindowScript   = redis.NewScript(fixedWindowSource)
+	slidingWindowScript = redis.NewScript(slidingWindowSource)
+)
+
+// windowKey returns the Redis key of the counter for window index.
+func (c redisConfig) windowKey(kind string, id Identity, index int64) string {
+	return c.keyFor(kind, id) + ":" + strconv.FormatInt(index, 10)
+}
+
+// RedisFixedWindowLimiter is a distributed RateLimiter implementing a fixed
+// window counter on Redis: at most limit.Rate events per epoch-aligned window
+// of limit.Period. Burst is not used.
+//
+// Each window is a single integer under
+// "{prefix}fixed:{namespace}:{key}:{window}" that expires when the window
+// ends.
+type RedisFixedWindowLimiter struct {
+	client *redis.Client
+	redisConfig
+}
+
+// NewRedisFixedWindowLimiter validates connectivity and loads the embedded Lua
+// script. It accepts the same options as NewRedisLimiter.
+func NewRedisFixedWindowLimiter(client *redis.Client, opts ...Option) (*RedisFixedWindowLimiter, error) {
+	limiter := &RedisFixedWindowLimiter{
+		client:      client,
+		redisConfig: newRedisConfig(opts),
+	}
+
+	if err := limiter.connect(client, fixedWindowScript); err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// Allow counts one event if the current window has room for it.
+func (r *RedisFixedWindowLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return r.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN counts n events if the current window has room for all of them.
+func (r *RedisFixedWindowLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkWindowCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	now := time.Now()
+	index, elapsed := windowOf(now, limit.Period)
+
+	result, err := fixedWindowScript.EvalSha(ctx, r.client, []string{r.windowKey(fixedKind, id, index)},
+		now.UnixMicro(),                         // ARGV[1]
+		(limit.Period - elapsed).Microseconds(), // ARGV[2]
+		limit.Rate,                              // ARGV[3]
+		n,                                       // ARGV[4]
+	).Result()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return Decision{}, backendError(err)
+	}
+
+	dec, err := parseDecision(result)
+	if err != nil {
+		r.recordError(id, "invalid_format")
+		return Decision{}, err
+	}
+
+	r.recordDecision(id, dec)
+	return dec, nil
+}
+
+// RedisSlidingWindowLimiter is a distributed RateLimiter implementing the
+// sliding window counter algorithm on Redis. It approximates the number of
+// events in the rolling limit.Period from the counts of the current and
+// previous epoch-aligned windows and allows at most limit.Rate; Burst is not
+// used.
+//
+// Each window is a single integer under
+// "{prefix}sliding:{namespace}:{key}:{window}" kept for two periods, so an
+// identity never costs more than two keys regardless of Rate.
+type RedisSlidingWindowLimiter struct {
+	client *redis.Client
+	redisConfig
+}
+
+// NewRedisSlidingWindowLimiter validates connectivity and loads the embedded
+// Lua script. It accepts the same options as NewRedisLimiter.
+func NewRedisSlidingWindowLimiter(client *redis.Client, opts ...Option) (*RedisSlidingWindowLimiter, error) {
+	limiter := &RedisSlidingWindowLimiter{
+		client:      client,
+		redisConfig: newRedisConfig(opts),
+	}
+
+	if err := limiter.connect(client, slidingWindowScript); err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// Allow counts one event if the estimated rolling count has room for it.
+func (r *RedisSlidingWindowLimiter) Allow(ctx context.Context, id Identity, limit Limit) (Decision, error) {
+	return r.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN counts n events if the estimated rolling count has room for all of
+// them.
+func (r *RedisSlidingWindowLimiter) AllowN(ctx context.Context, id Identity, limit Limit, n int64) (Decision, error) {
+	if err := checkWindowCost(limit, n); err != nil {
+		return Decision{}, err
+	}
+
+	now := time.Now()
+	index, elapsed := windowOf(now, limit.Period)
+	keys := []string{
+		r.windowKey(slidingKind, id, index),
+		r.windowKey(slidingKind, id, index-1),
+	}
+
+	result, err := slidingWindowScript.EvalSha(ctx, r.client, keys,
+		now.UnixMicro(),             // ARGV[1]
+		limit.Period.Microseconds(), // ARGV[2]
+		elapsed.Microseconds(),      // ARGV[3]
+		limit.Rate,                  // ARGV[4]
+		n,                           // ARGV[5]
+	).Result()
+	if err != nil {
+		r.recordError(id, "redis_eval")
+		return Decision{}, backendError(err)
+	}
+
+	dec, err := parseDecision(result)
+	if err != nil {
+		r.recordError(id, "invalid_format")
+		return Decision{}, err
+	}
+
+	r.recordDecision(id, dec)
+	return dec, nil
+}
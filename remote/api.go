@@ -0,0 +1,166 @@
+// Package remote serves a limiter.RateLimiter over HTTP/JSON, so services in
+// any language can share the same buckets, and provides a Go Client for it.
+//
+// The API has three endpoints, all POST with a JSON body:
+//
+//	POST /v1/allow  {"namespace": "user", "key": "u42", "rate": 10, "period": "1s", "burst": 20, "cost": 1}
+//	             -> {"allowed": true, "remaining": 19, "retry_after": 0, "reset_time": "2025-01-01T00:00:00Z"}
+//	POST /v1/peek   {"namespace": "user", "key": "u42", "rate": 10, "period": "1s", "burst": 20}
+//	             -> {"tokens": 19.4, "remaining": 19, "time_to_full": 0.06, "next_token_at": "..."}
+//	POST /v1/reset  {"namespace": "user", "key": "u42"}
+//	             -> {}
+//
+// Durations in requests are strings such as "1s" or "15m"; durations in
+// responses are seconds as numbers. Errors are returned as
+// {"error": "...", "code": "..."} with one of the ErrorCode values, which the
+// Client maps back to the limiter sentinels.
+//
+// Client implements limiter.RateLimiter, so a Go service can switch between
+// an embedded limiter and the shared service without other changes.
+package remote
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/manenim/gateway-rate-limiter/policy"
+)
+
+// ErrUnsupported is returned when the server's limiter does not implement an
+// operation, such as Peek on a limiter that is not a limiter.Peeker.
+var ErrUnsupported = errors.New("remote: operation not supported by the server's limiter")
+
+// LimitRequest identifies a bucket and its limit. It is the body of
+// /v1/allow and /v1/peek.
+type LimitRequest struct {
+	Namespace limiter.Namespace `json:"namespace"`
+	Key       string            `json:"key"`
+	Rate      int64             `json:"rate"`
+	Period    policy.Duration   `json:"period"`
+	Burst     int64             `json:"burst"`
+	// Cost is the number of tokens to take, for /v1/allow. It defaults to 1.
+	Cost int64 `json:"cost,omitempty"`
+}
+
+func newLimitRequest(id limiter.Identity, limit limiter.Limit, n int64) LimitRequest {
+	return LimitRequest{
+		Namespace: id.Namespace,
+		Key:       id.Key,
+		Rate:      limit.Rate,
+		Period:    policy.Duration(limit.Period),
+		Burst:     limit.Burst,
+		Cost:      n,
+	}
+}
+
+func (r LimitRequest) identity() limiter.Identity {
+	return limiter.Identity{Namespace: r.Namespace, Key: r.Key}
+}
+
+func (r LimitRequest) limit() limiter.Limit {
+	return limiter.Limit{Rate: r.Rate, Period: time.Duration(r.Period), Burst: r.Burst}
+}
+
+// AllowResponse is the body returned by /v1/allow.
+type AllowResponse struct {
+	Allowed   bool  `json:"allowed"`
+	Remaining int64 `json:"remaining"`
+	// RetryAfter is in seconds.
+	RetryAfter float64   `json:"retry_after"`
+	ResetTime  time.Time `json:"reset_time"`
+}
+
+func newAllowResponse(dec limiter.Decision) AllowResponse {
+	return AllowResponse{
+		Allowed:    dec.Allow,
+		Remaining:  dec.Remaining,
+		RetryAfter: dec.RetryAfter.Seconds(),
+		ResetTime:  dec.ResetTime,
+	}
+}
+
+func (r AllowResponse) decision() limiter.Decision {
+	return limiter.Decision{
+		Allow:      r.Allowed,
+		Remaining:  r.Remaining,
+		RetryAfter: seconds(r.RetryAfter),
+		ResetTime:  r.ResetTime,
+	}
+}
+
+// PeekResponse is the body returned by /v1/peek.
+type PeekResponse struct {
+	Tokens    float64 `json:"tokens"`
+	Remaining int64   `json:"remaining"`
+	// TimeToFull is in seconds.
+	TimeToFull  float64   `json:"time_to_full"`
+	NextTokenAt time.Time `json:"next_token_at"`
+}
+
+func newPeekResponse(st limiter.BucketState) PeekResponse {
+	return PeekResponse{
+		Tokens:      st.Tokens,
+		Remaining:   st.Remaining,
+		TimeToFull:  st.TimeToFull.Seconds(),
+		NextTokenAt: st.NextTokenAt,
+	}
+}
+
+func (r PeekResponse) state() limiter.BucketState {
+	return limiter.BucketState{
+		Tokens:      r.Tokens,
+		Remaining:   r.Remaining,
+		TimeToFull:  seconds(r.TimeToFull),
+		NextTokenAt: r.NextTokenAt,
+	}
+}
+
+// ResetRequest is the body of /v1/reset.
+type ResetRequest struct {
+	Namespace limiter.Namespace `json:"namespace"`
+	Key       string            `json:"key"`
+}
+
+// ErrorCode classifies an ErrorResponse.
+type ErrorCode string
+
+const (
+	CodeBadRequest         ErrorCode = "bad_request"
+	CodeInvalidLimit       ErrorCode = "invalid_limit"
+	CodeInvalidCost        ErrorCode = "invalid_cost"
+	CodeCostExceedsBurst   ErrorCode = "cost_exceeds_burst"
+	CodeReservedNamespace  ErrorCode = "reserved_namespace"
+	CodeBackendUnavailable ErrorCode = "backend_unavailable"
+	CodeUnsupported        ErrorCode = "unsupported"
+	CodeInternal           ErrorCode = "internal"
+)
+
+// ErrorResponse is the body returned with every non-2xx status.
+type ErrorResponse struct {
+	Error string    `json:"error"`
+	Code  ErrorCode `json:"code"`
+}
+
+// codes maps each ErrorCode to its HTTP status and, where there is one, the
+// sentinel error it stands for.
+var codes = []struct {
+	code   ErrorCode
+	status int
+	err    error
+}{
+	{CodeCostExceedsBurst, http.StatusBadRequest, limiter.ErrCostExceedsBurst},
+	{CodeInvalidCost, http.StatusBadRequest, limiter.ErrInvalidCost},
+	{CodeInvalidLimit, http.StatusBadRequest, limiter.ErrInvalidLimit},
+	{CodeReservedNamespace, http.StatusBadRequest, limiter.ErrReservedNamespace},
+	{CodeBackendUnavailable, http.StatusServiceUnavailable, limiter.ErrBackendUnavailable},
+	{CodeUnsupported, http.StatusNotImplemented, ErrUnsupported},
+	{CodeBadRequest, http.StatusBadRequest, nil},
+	{CodeInternal, http.StatusInternalServerError, nil},
+}
+
+// seconds converts a number of seconds into a Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
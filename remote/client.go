@@ -0,0 +1,144 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// Client calls a remote limiter service. It implements limiter.RateLimiter
+// and limiter.Peeker, and has a Reset method, so it can stand in for an
+// embedded limiter. Transport failures and non-JSON replies are reported as
+// limiter.ErrBackendUnavailable, so callers keep their fail-open or
+// fail-closed policy.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests (default
+// http.DefaultClient). Use its Timeout, or the request contexts, to bound
+// calls.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(cl *Client) {
+		cl.http = c
+	}
+}
+
+// WithUnixSocket sends every request over the Unix socket at path instead of
+// TCP. The host in the base URL is then ignored.
+func WithUnixSocket(path string) ClientOption {
+	return func(cl *Client) {
+		var d net.Dialer
+		cl.http = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		}
+	}
+}
+
+// NewClient returns a Client for the service at baseURL, such as
+// "http://limiter:8081". For a Unix socket, use "http://unix" with
+// WithUnixSocket.
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Allow is shorthand for AllowN(ctx, id, limit, 1).
+func (c *Client) Allow(ctx context.Context, id limiter.Identity, limit limiter.Limit) (limiter.Decision, error) {
+	return c.AllowN(ctx, id, limit, 1)
+}
+
+// AllowN asks the service whether a request costing n tokens is allowed.
+func (c *Client) AllowN(ctx context.Context, id limiter.Identity, limit limiter.Limit, n int64) (limiter.Decision, error) {
+	// Checked here because the service treats an omitted cost as 1.
+	if n < 1 {
+		return limiter.Decision{}, fmt.Errorf("%w: got %d", limiter.ErrInvalidCost, n)
+	}
+
+	var resp AllowResponse
+	if err := c.call(ctx, "/v1/allow", newLimitRequest(id, limit, n), &resp); err != nil {
+		return limiter.Decision{}, err
+	}
+	return resp.decision(), nil
+}
+
+// Peek reports the identity's bucket without consuming tokens.
+func (c *Client) Peek(ctx context.Context, id limiter.Identity, limit limiter.Limit) (limiter.BucketState, error) {
+	var resp PeekResponse
+	if err := c.call(ctx, "/v1/peek", newLimitRequest(id, limit, 0), &resp); err != nil {
+		return limiter.BucketState{}, err
+	}
+	return resp.state(), nil
+}
+
+// Reset clears the identity's bucket.
+func (c *Client) Reset(ctx context.Context, id limiter.Identity) error {
+	return c.call(ctx, "/v1/reset", ResetRequest{Namespace: id.Namespace, Key: id.Key}, &struct{}{})
+}
+
+// call POSTs req to path and decodes the reply into resp, or returns the
+// error the service reported.
+func (c *Client) call(ctx context.Context, path string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %w", limiter.ErrBackendUnavailable, err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return responseError(httpResp)
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("%w: decode response: %w", limiter.ErrBackendUnavailable, err)
+	}
+	return nil
+}
+
+// responseError converts an error reply into an error that matches the
+// sentinel of its code.
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+
+	var e ErrorResponse
+	if err := json.Unmarshal(data, &e); err != nil || e.Code == "" {
+		// Not the service talking, for example a proxy in between.
+		return fmt.Errorf("%w: %s", limiter.ErrBackendUnavailable, resp.Status)
+	}
+	for _, c := range codes {
+		if c.code == e.Code && c.err != nil {
+			return fmt.Errorf("%w: remote: %s", c.err, e.Error)
+		}
+	}
+	return fmt.Errorf("remote: %s (%s)", e.Error, e.Code)
+}
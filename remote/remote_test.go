@@ -0,0 +1,139 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+func newTestClient(t *testing.T, l limiter.RateLimiter) *Client {
+	t.Helper()
+	srv := httptest.NewServer(NewHandler(l))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL)
+}
+
+func TestClient_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, limiter.NewMemoryLimiter())
+	id := limiter.Identity{Namespace: "user", Key: "u42"}
+	limit := limiter.Limit{Rate: 1, Period: time.Minute, Burst: 3}
+
+	dec, err := c.AllowN(ctx, id, limit, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Allow || dec.Remaining != 1 {
+		t.Errorf("Expected 2 tokens taken with 1 remaining, got %+v", dec)
+	}
+
+	st, err := c.Peek(ctx, id, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Remaining != 1 || st.TimeToFull < 110*time.Second || st.TimeToFull > 2*time.Minute {
+		t.Errorf("Expected 1 remaining and about 2m to full, got %+v", st)
+	}
+
+	c.Allow(ctx, id, limit)
+	dec, err = c.Allow(ctx, id, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Allow || dec.RetryAfter < 50*time.Second || time.Until(dec.ResetTime) < 50*time.Second {
+		t.Errorf("Expected a denial with about a minute to wait, got %+v", dec)
+	}
+
+	if err := c.Reset(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if dec, _ := c.Allow(ctx, id, limit); !dec.Allow || dec.Remaining != 2 {
+		t.Errorf("Expected a full bucket after Reset, got %+v", dec)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, limiter.NewMemoryLimiter())
+	id := limiter.Identity{Namespace: "user", Key: "u42"}
+	limit := limiter.Limit{Rate: 1, Period: time.Minute, Burst: 3}
+
+	if _, err := c.Allow(ctx, id, limiter.Limit{Rate: 1, Period: time.Minute}); !errors.Is(err, limiter.ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := c.AllowN(ctx, id, limit, 4); !errors.Is(err, limiter.ErrCostExceedsBurst) {
+		t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+	}
+	if _, err := c.AllowN(ctx, id, limit, 0); !errors.Is(err, limiter.ErrInvalidCost) {
+		t.Errorf("Expected ErrInvalidCost, got %v", err)
+	}
+
+	// A limiter without Peek or Reset.
+	gcra := newTestClient(t, limiter.NewMemoryGCRALimiter())
+	if _, err := gcra.Peek(ctx, id, limit); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for Peek, got %v", err)
+	}
+
+	down := NewClient("http://127.0.0.1:1")
+	if _, err := down.Allow(ctx, id, limit); !errors.Is(err, limiter.ErrBackendUnavailable) {
+		t.Errorf("Expected ErrBackendUnavailable for an unreachable service, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := c.Allow(cancelled, id, limit); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled to be preserved, got %v", err)
+	}
+}
+
+func TestHandler_BadRequest(t *testing.T) {
+	h := NewHandler(limiter.NewMemoryLimiter())
+
+	tests := map[string]string{
+		"Malformed":    `{"namespace":`,
+		"UnknownField": `{"namespace": "user", "key": "u42", "limit": 5}`,
+		"BadPeriod":    `{"namespace": "user", "key": "u42", "rate": 1, "period": 60, "burst": 1}`,
+	}
+	for name, body := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/allow", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"bad_request"`) {
+			t.Errorf("%s: expected a bad_request error, got %d %s", name, w.Code, w.Body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/allow", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", w.Code)
+	}
+}
+
+func TestClient_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limiter.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: NewHandler(limiter.NewMemoryLimiter())}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	c := NewClient("http://unix", WithUnixSocket(path))
+	dec, err := c.Allow(context.Background(), limiter.Identity{Namespace: "user", Key: "u1"},
+		limiter.Limit{Rate: 1, Period: time.Second, Burst: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dec.Allow {
+		t.Errorf("Expected the first request to be allowed, got %+v", dec)
+	}
+}
@@ -0,0 +1,115 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// maxBodyBytes bounds request bodies; a valid request is far smaller.
+const maxBodyBytes = 64 << 10
+
+// resetter is the part of limiter.Admin the server uses.
+type resetter interface {
+	Reset(ctx context.Context, id limiter.Identity) error
+}
+
+// NewHandler serves the API backed by l. /v1/peek requires l to be a
+// limiter.Peeker and /v1/reset to have a Reset method; otherwise they return
+// CodeUnsupported.
+func NewHandler(l limiter.RateLimiter) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v1/allow", func(w http.ResponseWriter, r *http.Request) {
+		var req LimitRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		if req.Cost == 0 {
+			req.Cost = 1
+		}
+
+		dec, err := l.AllowN(r.Context(), req.identity(), req.limit(), req.Cost)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newAllowResponse(dec))
+	})
+
+	mux.HandleFunc("POST /v1/peek", func(w http.ResponseWriter, r *http.Request) {
+		var req LimitRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		p, ok := l.(limiter.Peeker)
+		if !ok {
+			writeError(w, ErrUnsupported)
+			return
+		}
+
+		st, err := p.Peek(r.Context(), req.identity(), req.limit())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newPeekResponse(st))
+	})
+
+	mux.HandleFunc("POST /v1/reset", func(w http.ResponseWriter, r *http.Request) {
+		var req ResetRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		rs, ok := l.(resetter)
+		if !ok {
+			writeError(w, ErrUnsupported)
+			return
+		}
+
+		if err := rs.Reset(r.Context(), limiter.Identity{Namespace: req.Namespace, Key: req.Key}); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	})
+
+	return mux
+}
+
+// decode reads the JSON body of r into v, rejecting unknown fields, and
+// writes a CodeBadRequest error if it cannot.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("invalid request body: %v", err),
+			Code:  CodeBadRequest,
+		})
+		return false
+	}
+	return true
+}
+
+// writeError writes err with the code and status of the first sentinel it
+// matches, or CodeInternal.
+func writeError(w http.ResponseWriter, err error) {
+	for _, c := range codes {
+		if c.err != nil && errors.Is(err, c.err) {
+			writeJSON(w, c.status, ErrorResponse{Error: err.Error(), Code: c.code})
+			return
+		}
+	}
+	writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: CodeInternal})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,110 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// noMaxWait is passed to the internal reserve functions when a reservation may
+// be scheduled arbitrarily far in the future.
+const noMaxWait = time.Duration(math.MaxInt64)
+
+// Reservation holds tokens that have already been deducted from a bucket on
+// behalf of a caller who agreed to wait for them.
+//
+// The bucket may go into debt to back a reservation: later callers see a
+// longer RetryAfter until the debt has been refilled.
+type Reservation struct {
+	tokens    int64
+	timeToAct time.Time
+	refund    func(ctx context.Context) error
+	cancelled atomic.Bool
+}
+
+func newReservation(tokens int64, timeToAct time.Time, refund func(ctx context.Context) error) *Reservation {
+	return &Reservation{
+		tokens:    tokens,
+		timeToAct: timeToAct,
+		refund:    refund,
+	}
+}
+
+// Tokens returns the number of tokens held by the reservation.
+func (r *Reservation) Tokens() int64 {
+	return r.tokens
+}
+
+// TimeToAct returns the time at which the reserved tokens become available.
+func (r *Reservation) TimeToAct() time.Time {
+	return r.timeToAct
+}
+
+// Delay returns how long the caller must wait before acting on the
+// reservation. It is 0 if the tokens are already available.
+func (r *Reservation) Delay() time.Duration {
+	d := time.Until(r.timeToAct)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Cancel returns the reserved tokens to the bucket (capped at Burst) so other
+// callers can use them. Cancelling a reservation whose time to act has already
+// passed, or cancelling twice, is a no-op.
+func (r *Reservation) Cancel(ctx context.Context) error {
+	if !time.Now().Before(r.timeToAct) {
+		return nil
+	}
+	if !r.cancelled.CompareAndSwap(false, true) {
+		return nil
+	}
+	return r.refund(ctx)
+}
+
+// waitN implements WaitN for any backend on top of its reserve function. The
+// deadline of ctx, if any, is handed to reserve as the maximum acceptable wait
+// so that a request which cannot be served in time fails fast without taking
+// tokens from anyone else.
+func waitN(ctx context.Context, reserve func(maxWait time.Duration) (*Reservation, error)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	maxWait := noMaxWait
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = max(time.Until(deadline), 0)
+	}
+
+	res, err := reserve(maxWait)
+	if err != nil {
+		return err
+	}
+
+	delay := res.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Hand the tokens back so they are not wasted. The original context is
+		// already done, so the refund must not inherit its cancellation.
+		_ = res.Cancel(context.WithoutCancel(ctx))
+		return ctx.Err()
+	}
+}
+
+// waitTooLong builds the error returned when a reservation would have to wait
+// longer than the caller's deadline allows.
+func waitTooLong(wait, maxWait time.Duration) error {
+	return fmt.Errorf("%w: need to wait %v, deadline allows %v", ErrWaitExceedsDeadline, wait, maxWait)
+}
@@ -0,0 +1,125 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testReserver runs the same reservation checks against any backend.
+func testReserver(t *testing.T, r Reserver, newID func(string) Identity) {
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 2}
+
+	t.Run("ReserveIntoDebt", func(t *testing.T) {
+		ctx := context.Background()
+		id := newID("reserve")
+
+		for i := 0; i < 2; i++ {
+			res, err := r.ReserveN(ctx, id, limit, 1)
+			if err != nil {
+				t.Fatalf("ReserveN failed: %v", err)
+			}
+			if res.Delay() != 0 {
+				t.Fatalf("Reservation %d should be immediate, got delay %v", i, res.Delay())
+			}
+		}
+
+		res, err := r.ReserveN(ctx, id, limit, 2)
+		if err != nil {
+			t.Fatalf("ReserveN failed: %v", err)
+		}
+		// Two tokens at 10/s take ~200ms to earn.
+		if d := res.Delay(); d < 150*time.Millisecond || d > 200*time.Millisecond {
+			t.Errorf("Expected delay of ~200ms, got %v", d)
+		}
+		if res.Tokens() != 2 {
+			t.Errorf("Expected reservation to hold 2 tokens, got %d", res.Tokens())
+		}
+
+		// Cancelling returns the tokens, so the next reservation is served
+		// from the refilled balance instead of queueing behind the debt.
+		if err := res.Cancel(ctx); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+		next, err := r.ReserveN(ctx, id, limit, 1)
+		if err != nil {
+			t.Fatalf("ReserveN failed: %v", err)
+		}
+		if d := next.Delay(); d > 100*time.Millisecond {
+			t.Errorf("Expected cancelled tokens to be returned, got delay %v", d)
+		}
+	})
+
+	t.Run("WaitBlocks", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		id := newID("wait")
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if err := r.WaitN(ctx, id, limit, 1); err != nil {
+				t.Fatalf("WaitN failed: %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+			t.Errorf("Expected the third call to wait ~100ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("WaitFailsFast", func(t *testing.T) {
+		id := newID("wait_deadline")
+		if _, err := r.ReserveN(context.Background(), id, limit, 2); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := r.WaitN(ctx, id, limit, 2)
+		if !errors.Is(err, ErrWaitExceedsDeadline) {
+			t.Fatalf("Expected ErrWaitExceedsDeadline, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+			t.Errorf("Expected WaitN to fail fast, took %v", elapsed)
+		}
+	})
+}
+
+func TestMemoryLimiter_Reserve(t *testing.T) {
+	testReserver(t, NewMemoryLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisLimiter_Reserve(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testReserver(t, limiter, uniqueIdentity)
+}
+
+func TestMemoryLimiter_DeniedWhileInDebt(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryLimiter()
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 2}
+	id := Identity{Namespace: "test", Key: "debtor"}
+
+	if _, err := limiter.ReserveN(ctx, id, limit, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.ReserveN(ctx, id, limit, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	dec, _ := limiter.Allow(ctx, id, limit)
+	if dec.Allow {
+		t.Fatal("Expected Allow to be denied while the bucket is in debt")
+	}
+	if dec.Remaining != 0 {
+		t.Errorf("Expected Remaining to be clamped to 0, got %d", dec.Remaining)
+	}
+	// 2 tokens of debt plus 1 for this request at 10/s.
+	if dec.RetryAfter < 250*time.Millisecond {
+		t.Errorf("Expected RetryAfter to include the debt, got %v", dec.RetryAfter)
+	}
+}
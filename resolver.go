@@ -0,0 +1,204 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PolicyResolver maps an identity to the Limit that applies to it, for
+// example by looking up the customer's plan.
+type PolicyResolver interface {
+	// Resolve returns the identity's Limit, or an error wrapping ErrNoPolicy
+	// if the resolver has none for it.
+	Resolve(ctx context.Context, id Identity) (Limit, error)
+}
+
+// ResolverFunc adapts an ordinary function to a PolicyResolver.
+type ResolverFunc func(ctx context.Context, id Identity) (Limit, error)
+
+// Resolve calls f(ctx, id).
+func (f ResolverFunc) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	return f(ctx, id)
+}
+
+// CostResolver is a PolicyResolver that also knows how many tokens one
+// request by the identity costs, such as policy.Store. PolicyLimiter.Allow
+// and the middleware package charge that cost instead of 1. ResolverChain
+// and CachingResolver resolve the Limit only.
+type CostResolver interface {
+	PolicyResolver
+	// ResolveCost returns the identity's Limit and the cost of one request.
+	ResolveCost(ctx context.Context, id Identity) (Limit, int64, error)
+}
+
+// StaticResolver is a fixed set of per-identity limits.
+type StaticResolver map[Identity]Limit
+
+// Resolve returns the identity's entry.
+func (s StaticResolver) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	if limit, ok := s[id]; ok {
+		return limit, nil
+	}
+	return Limit{}, fmt.Errorf("%w for identity %s:%s", ErrNoPolicy, id.Namespace, id.Key)
+}
+
+// NamespaceResolver gives every identity in a namespace the same default
+// limit.
+type NamespaceResolver map[Namespace]Limit
+
+// Resolve returns the entry for the identity's namespace.
+func (n NamespaceResolver) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	if limit, ok := n[id.Namespace]; ok {
+		return limit, nil
+	}
+	return Limit{}, fmt.Errorf("%w for namespace %q", ErrNoPolicy, id.Namespace)
+}
+
+// TierResolver resolves limits by plan tier: Tier looks up the identity's
+// tier (such as "free", "pro" or "enterprise") and Limits holds the limit of
+// each tier.
+type TierResolver struct {
+	Tier   func(ctx context.Context, id Identity) (string, error)
+	Limits map[string]Limit
+}
+
+// Resolve looks up the identity's tier and returns its limit.
+func (t TierResolver) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	tier, err := t.Tier(ctx, id)
+	if err != nil {
+		return Limit{}, err
+	}
+	if limit, ok := t.Limits[tier]; ok {
+		return limit, nil
+	}
+	return Limit{}, fmt.Errorf("%w for tier %q", ErrNoPolicy, tier)
+}
+
+// ResolverChain tries each resolver in turn and returns the first limit
+// found, so per-identity overrides can be listed before namespace defaults.
+// Errors other than ErrNoPolicy are returned immediately.
+type ResolverChain []PolicyResolver
+
+// Resolve returns the first resolver's limit for the identity.
+func (c ResolverChain) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	for _, r := range c {
+		limit, err := r.Resolve(ctx, id)
+		if !errors.Is(err, ErrNoPolicy) {
+			return limit, err
+		}
+	}
+	return Limit{}, fmt.Errorf("%w for identity %s:%s", ErrNoPolicy, id.Namespace, id.Key)
+}
+
+// CachingResolver caches the limits returned by another resolver for a fixed
+// TTL, for resolvers that hit a database or remote service. Errors are not
+// cached.
+type CachingResolver struct {
+	resolver PolicyResolver
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	entries   map[Identity]cachedLimit
+	nextSweep time.Time
+}
+
+type cachedLimit struct {
+	limit   Limit
+	expires time.Time
+}
+
+// NewCachingResolver wraps r so that each identity's limit is resolved at
+// most once per ttl.
+func NewCachingResolver(r PolicyResolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		resolver: r,
+		ttl:      ttl,
+		entries:  make(map[Identity]cachedLimit),
+	}
+}
+
+// Resolve returns the cached limit for the identity, resolving it again once
+// it has expired.
+func (c *CachingResolver) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.limit, nil
+	}
+
+	// Resolve without holding the lock; concurrent misses for the same
+	// identity may both call the wrapped resolver.
+	limit, err := c.resolver.Resolve(ctx, id)
+	if err != nil {
+		return Limit{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[id] = cachedLimit{limit: limit, expires: now.Add(c.ttl)}
+	if now.After(c.nextSweep) {
+		// Drop expired entries at most once per TTL, so identities that are
+		// never seen again do not accumulate.
+		for key, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, key)
+			}
+		}
+		c.nextSweep = now.Add(c.ttl)
+	}
+	return limit, nil
+}
+
+// Invalidate drops the cached limit of an identity, for example after its
+// plan changed.
+func (c *CachingResolver) Invalidate(id Identity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+}
+
+// PolicyLimiter pairs a RateLimiter with a PolicyResolver, so handlers can
+// call Allow(ctx, id) without building a Limit first.
+type PolicyLimiter struct {
+	limiter  RateLimiter
+	resolver PolicyResolver
+}
+
+// NewPolicyLimiter returns a PolicyLimiter that resolves each identity's
+// limit with r and enforces it with l.
+func NewPolicyLimiter(l RateLimiter, r PolicyResolver) *PolicyLimiter {
+	return &PolicyLimiter{limiter: l, resolver: r}
+}
+
+// Allow consumes one request's worth of tokens under the identity's resolved
+// limit: the resolved cost if the resolver is a CostResolver, otherwise 1.
+func (p *PolicyLimiter) Allow(ctx context.Context, id Identity) (Decision, error) {
+	cr, ok := p.resolver.(CostResolver)
+	if !ok {
+		return p.AllowN(ctx, id, 1)
+	}
+
+	limit, cost, err := cr.ResolveCost(ctx, id)
+	if err != nil {
+		return Decision{}, err
+	}
+	return p.limiter.AllowN(ctx, id, limit, cost)
+}
+
+// AllowN consumes n tokens under the identity's resolved limit. Resolver
+// errors, including ErrNoPolicy, are returned as is.
+func (p *PolicyLimiter) AllowN(ctx context.Context, id Identity, n int64) (Decision, error) {
+	limit, err := p.resolver.Resolve(ctx, id)
+	if err != nil {
+		return Decision{}, err
+	}
+	return p.limiter.AllowN(ctx, id, limit, n)
+}
@@ -0,0 +1,171 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	freeLimit = Limit{Rate: 1, Period: time.Second, Burst: 2}
+	proLimit  = Limit{Rate: 10, Period: time.Second, Burst: 20}
+)
+
+func TestTierResolver(t *testing.T) {
+	ctx := context.Background()
+	plans := map[string]string{"acme": "pro", "bob": "free", "eve": "trial"}
+
+	r := TierResolver{
+		Tier: func(ctx context.Context, id Identity) (string, error) {
+			plan, ok := plans[id.Key]
+			if !ok {
+				return "", errors.New("customer not found")
+			}
+			return plan, nil
+		},
+		Limits: map[string]Limit{"free": freeLimit, "pro": proLimit},
+	}
+
+	if limit, err := r.Resolve(ctx, Identity{Namespace: "customer", Key: "acme"}); err != nil || limit != proLimit {
+		t.Errorf("Expected the pro limit, got %+v (err %v)", limit, err)
+	}
+	if _, err := r.Resolve(ctx, Identity{Namespace: "customer", Key: "eve"}); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected ErrNoPolicy for an unknown tier, got %v", err)
+	}
+	if _, err := r.Resolve(ctx, Identity{Namespace: "customer", Key: "nobody"}); err == nil || errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected the lookup error, got %v", err)
+	}
+}
+
+func TestResolverChain(t *testing.T) {
+	ctx := context.Background()
+	vip := Identity{Namespace: "user", Key: "vip"}
+
+	chain := ResolverChain{
+		StaticResolver{vip: proLimit},
+		NamespaceResolver{"user": freeLimit},
+	}
+
+	if limit, err := chain.Resolve(ctx, vip); err != nil || limit != proLimit {
+		t.Errorf("Expected the per-identity override, got %+v (err %v)", limit, err)
+	}
+	if limit, err := chain.Resolve(ctx, Identity{Namespace: "user", Key: "u1"}); err != nil || limit != freeLimit {
+		t.Errorf("Expected the namespace default, got %+v (err %v)", limit, err)
+	}
+	if _, err := chain.Resolve(ctx, Identity{Namespace: "ip", Key: "1.2.3.4"}); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected ErrNoPolicy, got %v", err)
+	}
+
+	// Errors other than ErrNoPolicy stop the chain.
+	failing := ResolverChain{
+		ResolverFunc(func(ctx context.Context, id Identity) (Limit, error) {
+			return Limit{}, errors.New("database down")
+		}),
+		NamespaceResolver{"user": freeLimit},
+	}
+	if _, err := failing.Resolve(ctx, vip); err == nil || errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected the resolver error, got %v", err)
+	}
+}
+
+func TestCachingResolver(t *testing.T) {
+	ctx := context.Background()
+	id := Identity{Namespace: "user", Key: "u1"}
+
+	var (
+		calls atomic.Int32
+		fail  atomic.Bool
+	)
+	r := NewCachingResolver(ResolverFunc(func(ctx context.Context, id Identity) (Limit, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return Limit{}, errors.New("lookup failed")
+		}
+		return proLimit, nil
+	}), 50*time.Millisecond)
+
+	for range 3 {
+		if limit, err := r.Resolve(ctx, id); err != nil || limit != proLimit {
+			t.Fatalf("Expected the pro limit, got %+v (err %v)", limit, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected 1 lookup within the TTL, got %d", n)
+	}
+
+	r.Invalidate(id)
+	if _, err := r.Resolve(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected a lookup after Invalidate, got %d calls", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	fail.Store(true)
+	for range 2 {
+		if _, err := r.Resolve(ctx, id); err == nil {
+			t.Error("Expected the error once the entry expired")
+		}
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("Expected errors not to be cached, got %d calls", n)
+	}
+}
+
+func TestPolicyLimiter(t *testing.T) {
+	ctx := context.Background()
+	pl := NewPolicyLimiter(NewMemoryLimiter(), NamespaceResolver{"user": freeLimit})
+	id := Identity{Namespace: "user", Key: "u1"}
+
+	for i := range 2 {
+		if dec, err := pl.Allow(ctx, id); err != nil || !dec.Allow {
+			t.Fatalf("Request %d: expected allowed, got %+v (err %v)", i, dec, err)
+		}
+	}
+	if dec, err := pl.Allow(ctx, id); err != nil || dec.Allow {
+		t.Errorf("Expected the free burst of 2 to be exhausted, got %+v (err %v)", dec, err)
+	}
+
+	if _, err := pl.Allow(ctx, Identity{Namespace: "ip", Key: "1.2.3.4"}); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("Expected ErrNoPolicy, got %v", err)
+	}
+	if _, err := pl.AllowN(ctx, id, 3); !errors.Is(err, ErrCostExceedsBurst) {
+		t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+	}
+}
+
+// costResolver charges a fixed cost under a fixed limit.
+type costResolver struct {
+	limit Limit
+	cost  int64
+}
+
+func (c costResolver) Resolve(ctx context.Context, id Identity) (Limit, error) {
+	return c.limit, nil
+}
+
+func (c costResolver) ResolveCost(ctx context.Context, id Identity) (Limit, int64, error) {
+	return c.limit, c.cost, nil
+}
+
+func TestPolicyLimiter_ResolvedCost(t *testing.T) {
+	ctx := context.Background()
+	pl := NewPolicyLimiter(NewMemoryLimiter(), costResolver{limit: proLimit, cost: 15})
+	id := Identity{Namespace: "user", Key: "u1"}
+
+	dec, err := pl.Allow(ctx, id)
+	if err != nil || !dec.Allow {
+		t.Fatalf("Expected allowed, got %+v (err %v)", dec, err)
+	}
+	if want := proLimit.Burst - 15; dec.Remaining != want {
+		t.Errorf("Expected Allow to charge the resolved cost, leaving %d, got %d", want, dec.Remaining)
+	}
+
+	// AllowN still charges what the caller asks for.
+	if dec, err := pl.AllowN(ctx, id, 1); err != nil || dec.Remaining != proLimit.Burst-16 {
+		t.Errorf("Expected AllowN to charge 1, got %+v (err %v)", dec, err)
+	}
+}
@@ -0,0 +1,104 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxArgs bounds the number of arguments in one command.
+	maxArgs = 64
+	// maxArgBytes bounds the length of one argument.
+	maxArgBytes = 64 << 10
+)
+
+// errProtocol reports a malformed request. The connection is closed after
+// the error is written, since the stream can no longer be parsed.
+var errProtocol = errors.New("Protocol error")
+
+// readCommand reads one command: a RESP array of bulk strings as sent by
+// Redis clients, or an inline command (space-separated words on one line) as
+// typed into telnet. An empty inline line gives no arguments.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxArgs {
+		return nil, fmt.Errorf("%w: invalid multibulk length", errProtocol)
+	}
+	args := make([]string, 0, max(n, 0))
+	for range n {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("%w: expected '$', got %q", errProtocol, line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxArgBytes {
+			return nil, fmt.Errorf("%w: invalid bulk length", errProtocol)
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// readLine reads a line terminated by "\r\n" (or a bare "\n", which telnet
+// users may send) and returns it without the terminator.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", fmt.Errorf("%w: line too long", errProtocol)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(line[:len(line)-1]), "\r"), nil
+}
+
+// writer encodes RESP2 replies.
+type writer struct {
+	*bufio.Writer
+}
+
+func (w writer) simple(s string) {
+	w.WriteString("+" + s + "\r\n")
+}
+
+// error writes s as an error reply. By convention s starts with an upper
+// case error code such as "ERR".
+func (w writer) error(s string) {
+	// Line breaks would end the reply early.
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	w.WriteString("-" + s + "\r\n")
+}
+
+func (w writer) bulk(s string) {
+	w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+func (w writer) ints(vs ...int64) {
+	w.WriteString("*" + strconv.Itoa(len(vs)) + "\r\n")
+	for _, v := range vs {
+		w.WriteString(":" + strconv.FormatInt(v, 10) + "\r\n")
+	}
+}
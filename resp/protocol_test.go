@@ -0,0 +1,65 @@
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"Array", "*2\r\n$4\r\nPING\r\n$5\r\nhello\r\n", []string{"PING", "hello"}},
+		{"EmptyBulk", "*2\r\n$4\r\nECHO\r\n$0\r\n\r\n", []string{"ECHO", ""}},
+		{"BinaryBulk", "*2\r\n$4\r\nECHO\r\n$3\r\na\r\n\r\n", []string{"ECHO", "a\r\n"}},
+		{"Inline", "THROTTLE  user:1 5 1 60\r\n", []string{"THROTTLE", "user:1", "5", "1", "60"}},
+		{"InlineBareLF", "PING\n", []string{"PING"}},
+		{"EmptyInline", "\r\n", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCommand(bufio.NewReader(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadCommand_ProtocolError(t *testing.T) {
+	tests := map[string]string{
+		"BadLength":     "*x\r\n",
+		"TooManyArgs":   "*1000\r\n",
+		"NotBulk":       "*1\r\n:1\r\n",
+		"BadBulkLength": "*1\r\n$-1\r\n",
+		"Unterminated":  "*1\r\n$4\r\nPINGxx",
+	}
+	for name, input := range tests {
+		if _, err := readCommand(bufio.NewReader(strings.NewReader(input))); !errors.Is(err, errProtocol) {
+			t.Errorf("%s: expected a protocol error, got %v", name, err)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var b strings.Builder
+	w := writer{bufio.NewWriter(&b)}
+	w.simple("PONG")
+	w.error("ERR bad\r\nthing")
+	w.bulk("hi")
+	w.ints(1, -1)
+	w.Flush()
+
+	want := "+PONG\r\n-ERR bad  thing\r\n$2\r\nhi\r\n*2\r\n:1\r\n:-1\r\n"
+	if b.String() != want {
+		t.Errorf("Expected %q, got %q", want, b.String())
+	}
+}
@@ -0,0 +1,347 @@
+// Package resp serves a limiter.RateLimiter over the Redis wire protocol
+// (RESP), so any language with a Redis client can rate limit without a custom
+// SDK.
+//
+// The THROTTLE command takes a key, the burst, the rate per period, the
+// period and an optional cost (default 1), in the spirit of redis-cell's
+// CL.THROTTLE:
+//
+//	THROTTLE user:u42 20 10 1 1
+//	1) (integer) 1    allowed: 1 if allowed, 0 if limited
+//	2) (integer) 20   limit: the burst
+//	3) (integer) 19   remaining tokens
+//	4) (integer) -1   retry after, in seconds; -1 if allowed
+//	5) (integer) 1    reset: seconds until the bucket is full again
+//
+// The period is whole seconds or a Go duration such as "500ms" or "1m".
+// Unlike CL.THROTTLE, the burst is the bucket capacity itself and the first
+// element is 1 when the request is allowed. Errors, such as an invalid limit
+// or an unavailable backend, are returned as ERR replies.
+//
+// PING, ECHO and QUIT are also supported. Every key shares one
+// limiter.Namespace, "throttle" unless set with WithNamespace.
+//
+//	srv := resp.NewServer(l)
+//	ln, err := net.Listen("tcp", ":6380")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Fatal(srv.Serve(ln))
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+)
+
+// DefaultNamespace is the namespace of THROTTLE keys unless WithNamespace is
+// used.
+const DefaultNamespace limiter.Namespace = "throttle"
+
+// ErrServerClosed is returned by Serve after Shutdown or Close.
+var ErrServerClosed = errors.New("resp: server closed")
+
+// Server answers RESP commands with a limiter.RateLimiter.
+type Server struct {
+	limiter   limiter.RateLimiter
+	namespace limiter.Namespace
+	timeout   time.Duration
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closing   bool
+	wg        sync.WaitGroup
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithNamespace sets the namespace of THROTTLE keys.
+func WithNamespace(ns limiter.Namespace) Option {
+	return func(s *Server) {
+		s.namespace = ns
+	}
+}
+
+// WithTimeout bounds each limiter call with a context deadline (default
+// none). A deadline that passes fails the command with an ERR reply.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// NewServer returns a Server backed by l.
+func NewServer(l limiter.RateLimiter, opts ...Option) *Server {
+	s := &Server{
+		limiter:   l,
+		namespace: DefaultNamespace,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Serve accepts connections on ln until Shutdown or Close is called, when it
+// returns ErrServerClosed.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed
+	}
+	s.listeners[ln] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closing := s.closing
+			delete(s.listeners, ln)
+			s.mu.Unlock()
+			if closing {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// Shutdown stops accepting connections and lets each connection finish the
+// command it is running, then closes it. If ctx is done first, the remaining
+// connections are closed and ctx's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	// Wake connections blocked reading their next command.
+	for conn := range s.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+}
+
+// Close closes the listeners and every connection immediately.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closing = true
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := writer{bufio.NewWriter(conn)}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				w.error("ERR " + err.Error())
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.exec(w, args)
+		// Pipelined commands are answered together.
+		if r.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+		if quit {
+			return
+		}
+
+		s.mu.Lock()
+		closing := s.closing
+		s.mu.Unlock()
+		if closing && r.Buffered() == 0 {
+			return
+		}
+	}
+}
+
+// exec runs one command and writes its reply. It reports whether the
+// connection should be closed.
+func (s *Server) exec(w writer, args []string) (quit bool) {
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+
+	switch name {
+	case "PING":
+		switch len(args) {
+		case 0:
+			w.simple("PONG")
+		case 1:
+			w.bulk(args[0])
+		default:
+			w.error(arityError(name))
+		}
+	case "ECHO":
+		if len(args) != 1 {
+			w.error(arityError(name))
+			return false
+		}
+		w.bulk(args[0])
+	case "QUIT":
+		w.simple("OK")
+		return true
+	case "THROTTLE":
+		s.throttle(w, args)
+	default:
+		w.error(fmt.Sprintf("ERR unknown command '%s'", truncate(name)))
+	}
+	return false
+}
+
+// throttle runs THROTTLE key burst rate period [cost].
+func (s *Server) throttle(w writer, args []string) {
+	if len(args) != 4 && len(args) != 5 {
+		w.error(arityError("THROTTLE"))
+		return
+	}
+
+	burst, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		w.error("ERR burst is not an integer")
+		return
+	}
+	rate, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		w.error("ERR rate is not an integer")
+		return
+	}
+	period, err := parsePeriod(args[3])
+	if err != nil {
+		w.error("ERR period must be whole seconds or a duration such as 500ms")
+		return
+	}
+	cost := int64(1)
+	if len(args) == 5 {
+		if cost, err = strconv.ParseInt(args[4], 10, 64); err != nil {
+			w.error("ERR cost is not an integer")
+			return
+		}
+	}
+
+	id := limiter.Identity{Namespace: s.namespace, Key: args[0]}
+	limit := limiter.Limit{Rate: rate, Period: period, Burst: burst}
+
+	ctx := context.Background()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	dec, err := s.limiter.AllowN(ctx, id, limit, cost)
+	if err != nil {
+		w.error("ERR " + err.Error())
+		return
+	}
+
+	allowed, retryAfter := int64(0), ceilSeconds(dec.RetryAfter)
+	if dec.Allow {
+		allowed, retryAfter = 1, -1
+	}
+	w.ints(allowed, limit.Burst, dec.Remaining, retryAfter, resetSeconds(dec, limit))
+}
+
+// parsePeriod accepts whole seconds, as in CL.THROTTLE, or a Go duration.
+func parsePeriod(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// resetSeconds approximates the time until the bucket is full again from the
+// whole tokens remaining. Remaining is floored, so the fraction of a token
+// already refilled is ignored and the result may be up to one token's
+// interval late, never early.
+func resetSeconds(dec limiter.Decision, limit limiter.Limit) int64 {
+	missing := limit.Burst - dec.Remaining
+	if missing <= 0 {
+		return 0
+	}
+	return ceilSeconds(time.Duration(float64(missing) / float64(limit.Rate) * float64(limit.Period)))
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+func arityError(name string) string {
+	return fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name))
+}
+
+// truncate shortens a command name for an error reply.
+func truncate(name string) string {
+	if len(name) > 64 {
+		return name[:64] + "..."
+	}
+	return name
+}
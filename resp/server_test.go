@@ -0,0 +1,165 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/manenim/gateway-rate-limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+// startServer serves a MemoryLimiter on a loopback port and returns its
+// address.
+func startServer(t *testing.T, opts ...Option) (*Server, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(limiter.NewMemoryLimiter(), opts...)
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return srv, ln.Addr().String()
+}
+
+func TestServer_RedisClient(t *testing.T) {
+	ctx := context.Background()
+	_, addr := startServer(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2})
+	defer client.Close()
+
+	if got, err := client.Ping(ctx).Result(); err != nil || got != "PONG" {
+		t.Fatalf("Expected PONG, got %q, %v", got, err)
+	}
+
+	throttle := func(args ...any) []int64 {
+		t.Helper()
+		got, err := client.Do(ctx, append([]any{"THROTTLE"}, args...)...).Int64Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if got, want := throttle("user:1", 3, 1, 60, 2), []int64{1, 3, 1, -1, 120}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got, want := throttle("user:1", 3, 1, "1m"), []int64{1, 3, 0, -1, 180}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got := throttle("user:1", 3, 1, 60)
+	if got[0] != 0 || got[2] != 0 || got[3] < 59 || got[3] > 60 {
+		t.Errorf("Expected a denial with a minute to wait, got %v", got)
+	}
+	if got := throttle("user:2", 3, 1, 60); got[0] != 1 {
+		t.Errorf("Expected another key to have its own bucket, got %v", got)
+	}
+}
+
+func TestServer_Pipeline(t *testing.T) {
+	ctx := context.Background()
+	_, addr := startServer(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2})
+	defer client.Close()
+
+	cmds, err := client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for range 3 {
+			p.Do(ctx, "THROTTLE", "user:1", 2, 1, 60)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		t.Fatal(err)
+	}
+
+	var allowed []int64
+	for _, cmd := range cmds {
+		got, err := cmd.(*redis.Cmd).Int64Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowed = append(allowed, got[0])
+	}
+	if want := []int64{1, 1, 0}; !reflect.DeepEqual(allowed, want) {
+		t.Errorf("Expected %v, got %v", want, allowed)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	ctx := context.Background()
+	_, addr := startServer(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, Protocol: 2})
+	defer client.Close()
+
+	tests := []struct {
+		args []any
+		want string
+	}{
+		{[]any{"THROTTLE", "k", 1, 1}, "ERR wrong number of arguments for 'throttle' command"},
+		{[]any{"THROTTLE", "k", "x", 1, 60}, "ERR burst is not an integer"},
+		{[]any{"THROTTLE", "k", 1, 1, "soon"}, "ERR period must be"},
+		{[]any{"THROTTLE", "k", 0, 1, 60}, "ERR limiter: invalid limit"},
+		{[]any{"THROTTLE", "k", 1, 1, 60, 0}, "ERR limiter: cost must be at least 1"},
+		{[]any{"GET", "k"}, "ERR unknown command 'GET'"},
+	}
+	for _, tt := range tests {
+		err := client.Do(ctx, tt.args...).Err()
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%v: expected an error starting with %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
+
+func TestServer_Inline(t *testing.T) {
+	_, addr := startServer(t, WithNamespace("inline"))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("PING\r\nTHROTTLE k 5 1 1\r\nQUIT\r\n"))
+	r := bufio.NewReader(conn)
+	want := []string{"+PONG", "*5", ":1", ":5", ":4", ":-1", ":1", "+OK"}
+	for _, w := range want {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(line, "\r\n"); got != w {
+			t.Errorf("Expected %q, got %q", w, got)
+		}
+	}
+	if _, err := r.ReadByte(); err == nil {
+		t.Error("Expected the connection to be closed after QUIT")
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	srv, addr := startServer(t)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("PING\r\n"))
+	if line, _ := bufio.NewReader(conn).ReadString('\n'); line != "+PONG\r\n" {
+		t.Fatalf("Expected +PONG, got %q", line)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected idle connections to close, got %v", err)
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("Expected the listener to be closed")
+	}
+}
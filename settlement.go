@@ -0,0 +1,57 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Authorization holds an estimated cost that has already been deducted from a
+// bucket, to be reconciled with the actual cost once it is known (tokens
+// generated, bytes streamed).
+type Authorization struct {
+	estimate int64
+	settled  atomic.Bool
+	adjust   func(ctx context.Context, amount, maxDebt int64) error
+}
+
+func newAuthorization(estimate int64, adjust func(ctx context.Context, amount, maxDebt int64) error) *Authorization {
+	return &Authorization{
+		estimate: estimate,
+		adjust:   adjust,
+	}
+}
+
+// Estimate returns the number of tokens deducted by Authorize.
+func (a *Authorization) Estimate() int64 {
+	return a.estimate
+}
+
+// Settle reconciles the authorization with the actual cost. If actual is
+// below the estimate the difference is refunded (capped at Burst); if it is
+// above, the difference is charged, taking the bucket into debt of at most
+// maxDebt tokens. Any charge beyond that is forgiven, so with a maxDebt of 0
+// the bucket is at most drained to empty.
+//
+// Debt delays later requests until it has been refilled. Settling twice is a
+// no-op, but if the adjustment fails (for example with ErrBackendUnavailable)
+// the authorization stays unsettled and Settle can be retried.
+func (a *Authorization) Settle(ctx context.Context, actual, maxDebt int64) error {
+	if actual < 0 {
+		return fmt.Errorf("%w: actual cost %d", ErrInvalidCost, actual)
+	}
+	if maxDebt < 0 {
+		return fmt.Errorf("%w: max debt must not be negative, got %d", ErrInvalidLimit, maxDebt)
+	}
+	if !a.settled.CompareAndSwap(false, true) {
+		return nil
+	}
+	if actual == a.estimate {
+		return nil
+	}
+	if err := a.adjust(ctx, a.estimate-actual, maxDebt); err != nil {
+		a.settled.Store(false)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func testSettlement(t *testing.T, l interface {
+	Authorizer
+	Peeker
+}, newID func(string) Identity) {
+	ctx := context.Background()
+	// A slow refill keeps balances stable for the duration of the test.
+	limit := Limit{Rate: 1, Period: time.Hour, Burst: 10}
+
+	balance := func(t *testing.T, id Identity) float64 {
+		t.Helper()
+		st, err := l.Peek(ctx, id, limit)
+		if err != nil {
+			t.Fatalf("Peek failed: %v", err)
+		}
+		return st.Tokens
+	}
+	expectBalance := func(t *testing.T, id Identity, want float64) {
+		t.Helper()
+		if got := balance(t, id); math.Abs(got-want) > 0.01 {
+			t.Errorf("Expected balance %v, got %v", want, got)
+		}
+	}
+
+	t.Run("RefundsOverestimate", func(t *testing.T) {
+		id := newID("refund")
+
+		dec, auth, err := l.Authorize(ctx, id, limit, 5)
+		if err != nil || !dec.Allow || auth == nil {
+			t.Fatalf("Expected authorization, got %+v (err %v)", dec, err)
+		}
+		if auth.Estimate() != 5 {
+			t.Errorf("Expected estimate 5, got %d", auth.Estimate())
+		}
+		expectBalance(t, id, 5)
+
+		if err := auth.Settle(ctx, 2, 0); err != nil {
+			t.Fatal(err)
+		}
+		expectBalance(t, id, 8)
+
+		// Settling twice changes nothing.
+		if err := auth.Settle(ctx, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		expectBalance(t, id, 8)
+	})
+
+	t.Run("ChargesUnderestimateIntoBoundedDebt", func(t *testing.T) {
+		id := newID("debt")
+
+		_, auth, err := l.Authorize(ctx, id, limit, 8)
+		if err != nil || auth == nil {
+			t.Fatalf("Expected authorization (err %v)", err)
+		}
+
+		// 5 more tokens would leave -3, but only 2 tokens of debt are allowed.
+		if err := auth.Settle(ctx, 13, 2); err != nil {
+			t.Fatal(err)
+		}
+		expectBalance(t, id, -2)
+
+		if dec, _, err := l.Authorize(ctx, id, limit, 1); err != nil || dec.Allow {
+			t.Errorf("Expected denial while in debt, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("NoDebtDrainsToEmpty", func(t *testing.T) {
+		id := newID("nodebt")
+
+		_, auth, err := l.Authorize(ctx, id, limit, 6)
+		if err != nil || auth == nil {
+			t.Fatalf("Expected authorization (err %v)", err)
+		}
+		if err := auth.Settle(ctx, 20, 0); err != nil {
+			t.Fatal(err)
+		}
+		expectBalance(t, id, 0)
+	})
+
+	t.Run("DeniedHasNoAuthorization", func(t *testing.T) {
+		id := newID("denied")
+
+		if _, _, err := l.Authorize(ctx, id, limit, 10); err != nil {
+			t.Fatal(err)
+		}
+		dec, auth, err := l.Authorize(ctx, id, limit, 1)
+		if err != nil || dec.Allow || auth != nil {
+			t.Errorf("Expected denial without authorization, got %+v, %v (err %v)", dec, auth, err)
+		}
+	})
+
+	t.Run("InvalidActual", func(t *testing.T) {
+		_, auth, err := l.Authorize(ctx, newID("invalid"), limit, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := auth.Settle(ctx, -1, 0); !errors.Is(err, ErrInvalidCost) {
+			t.Errorf("Expected ErrInvalidCost, got %v", err)
+		}
+		if err := auth.Settle(ctx, 1, -1); !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("Expected ErrInvalidLimit for a negative max debt, got %v", err)
+		}
+	})
+}
+
+func TestAuthorization_SettleRetriesAfterError(t *testing.T) {
+	ctx := context.Background()
+	var calls []int64
+	fail := true
+	auth := newAuthorization(5, func(_ context.Context, amount, _ int64) error {
+		calls = append(calls, amount)
+		if fail {
+			return ErrBackendUnavailable
+		}
+		return nil
+	})
+
+	if err := auth.Settle(ctx, 2, 0); !errors.Is(err, ErrBackendUnavailable) {
+		t.Fatalf("Expected ErrBackendUnavailable, got %v", err)
+	}
+	fail = false
+	if err := auth.Settle(ctx, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Settle(ctx, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[1] != 3 {
+		t.Errorf("Expected the refund of 3 to be retried once, got %v", calls)
+	}
+}
+
+func TestMemoryLimiter_Settlement(t *testing.T) {
+	testSettlement(t, NewMemoryLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisLimiter_Settlement(t *testing.T) {
+	limiter, _ := newIntegrationLimiter(t)
+	testSettlement(t, limiter, uniqueIdentity)
+}
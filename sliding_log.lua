@@ -0,0 +1,33 @@
+-- Sliding window log: a sorted set of admitted events scored by their time in
+-- microseconds. At most `limit` events may fall in any rolling window.
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local member = ARGV[5]
+
+if not (window and window > 0 and limit and limit > 0) then
+    return redis.error_reply('INVALIDLIMIT window and limit must be positive')
+end
+
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local count = redis.call('ZCARD', key)
+
+if count + cost <= limit then
+    for i = 1, cost do
+        redis.call('ZADD', key, now, member .. ':' .. i)
+    end
+    redis.call('PEXPIRE', key, math.ceil(window / 1000))
+
+    return {1, limit - count - cost, '0', tostring(now / 1e6)}
+end
+
+-- Enough of the oldest events must expire to make room for `cost` more.
+local index = count + cost - limit - 1
+local entry = redis.call('ZRANGE', key, index, index, 'WITHSCORES')
+local retry_after = (tonumber(entry[2]) + window - now) / 1e6
+
+return {0, limit - count, tostring(retry_after), tostring(now / 1e6 + retry_after)}
@@ -0,0 +1,69 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testSlidingLog(t *testing.T, l RateLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 3, Period: 200 * time.Millisecond}
+
+	t.Run("RollingWindow", func(t *testing.T) {
+		id := newID("login")
+
+		if dec, err := l.Allow(ctx, id, limit); err != nil || !dec.Allow || dec.Remaining != 2 {
+			t.Fatalf("Expected first event allowed with 2 remaining, got %+v (err %v)", dec, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if dec, err := l.AllowN(ctx, id, limit, 2); err != nil || !dec.Allow || dec.Remaining != 0 {
+			t.Fatalf("Expected 2 more events allowed, got %+v (err %v)", dec, err)
+		}
+
+		dec, err := l.Allow(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Fatal("Expected the 4th event in the window to be denied")
+		}
+		// The first event leaves the window ~100ms from now.
+		if dec.RetryAfter <= 0 || dec.RetryAfter > 100*time.Millisecond {
+			t.Errorf("Expected RetryAfter until the oldest event expires, got %v", dec.RetryAfter)
+		}
+
+		time.Sleep(dec.RetryAfter + 10*time.Millisecond)
+
+		// Exactly one slot has been freed; a fixed window reset would free all.
+		if dec, err := l.Allow(ctx, id, limit); err != nil || !dec.Allow {
+			t.Fatalf("Expected the freed slot to be available, got %+v (err %v)", dec, err)
+		}
+		if dec, err := l.Allow(ctx, id, limit); err != nil || dec.Allow {
+			t.Errorf("Expected the window to be full again, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("CostExceedsWindow", func(t *testing.T) {
+		if _, err := l.AllowN(ctx, newID("sms"), limit, 4); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+		}
+	})
+}
+
+func TestMemorySlidingLogLimiter(t *testing.T) {
+	testSlidingLog(t, NewMemorySlidingLogLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisSlidingLogLimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisSlidingLogLimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisSlidingLogLimiter: %v", err)
+	}
+	testSlidingLog(t, limiter, uniqueIdentity)
+}
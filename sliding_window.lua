@@ -0,0 +1,38 @@
+-- Sliding window counter: KEYS[1] counts the current window and KEYS[2] the
+-- previous one. The rolling count is estimated by weighting the previous
+-- window by how much of it still overlaps. Times are in microseconds.
+local current_key = KEYS[1]
+local previous_key = KEYS[2]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local limit = tonumber(ARGV[4])
+local cost = tonumber(ARGV[5])
+
+if not (window and window > 0 and limit and limit > 0) then
+    return redis.error_reply('INVALIDLIMIT window and limit must be positive')
+end
+
+local current = tonumber(redis.call('GET', current_key) or '0')
+local previous = tonumber(redis.call('GET', previous_key) or '0')
+
+local estimate = previous * (1 - elapsed / window) + current
+
+if estimate + cost <= limit then
+    redis.call('INCRBY', current_key, cost)
+    -- The counter is still read as the previous window during the next one.
+    redis.call('PEXPIRE', current_key, math.ceil(2 * window / 1000))
+
+    return {1, math.floor(limit - estimate - cost), '0', tostring(now / 1e6)}
+end
+
+-- Mirrors slidingWait in window.go.
+local wait
+if current + cost > limit then
+    wait = window - elapsed + math.max(window * (1 - (limit - cost) / current), 0)
+else
+    wait = math.max(window * (1 - (limit - current - cost) / previous) - elapsed, 0)
+end
+local retry_after = wait / 1e6
+
+return {0, math.max(math.floor(limit - estimate), 0), tostring(retry_after), tostring(now / 1e6 + retry_after)}
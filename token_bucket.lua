@@ -4,6 +4,17 @@ local capacity = tonumber(ARGV[2])
 local now = tonumber(ARGV[3])
 local cost = tonumber(ARGV[4])
 
+if not (rate and rate > 0 and capacity and capacity > 0) then
+    return redis.error_reply('INVALIDLIMIT rate and capacity must be positive')
+end
+if not (cost and cost >= 1 and cost <= capacity) then
+    return redis.error_reply('INVALIDLIMIT cost must be between 1 and capacity')
+end
+
+rate, capacity = apply_override(KEYS[2], rate, capacity)
+if cost > capacity then
+    return cost_exceeds_burst(cost, capacity)
+end
 
 local state = redis.call('HMGET', key, 'tokens', 'last_refill')
 local tokens = tonumber(state[1])
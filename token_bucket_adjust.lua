@@ -0,0 +1,54 @@
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local amount = tonumber(ARGV[4])
+local floor = tonumber(ARGV[5])
+
+if not (rate and rate > 0 and capacity and capacity > 0) then
+    return redis.error_reply('INVALIDLIMIT rate and capacity must be positive')
+end
+
+rate, capacity = apply_override(KEYS[2], rate, capacity)
+
+local state = redis.call('HMGET', key, 'tokens', 'last_refill')
+local tokens = tonumber(state[1])
+local last_refill = tonumber(state[2])
+
+if tokens == nil then
+    -- A missing key is a full bucket; there is nothing to give back.
+    if amount >= 0 then
+        return tostring(capacity)
+    end
+    tokens = capacity
+    last_refill = now
+end
+
+local elapsed = now - last_refill
+if elapsed < 0 then
+    elapsed = 0
+end
+
+tokens = tokens + elapsed * rate
+
+if amount < 0 then
+    -- A charge may go into debt down to floor, but never pushes a bucket that
+    -- is already deeper in debt any further.
+    tokens = math.max(tokens + amount, math.min(floor, tokens))
+else
+    tokens = tokens + amount
+end
+
+if tokens > capacity then
+    tokens = capacity
+end
+
+redis.call('HMSET', key, 'tokens', tokens, 'last_refill', now)
+
+local ttl = math.ceil(((capacity - tokens) / rate) * 2)
+if ttl < 1 then
+    ttl = 1
+end
+redis.call('EXPIRE', key, ttl)
+
+return tostring(tokens)
@@ -0,0 +1,109 @@
+-- Evaluates several token buckets together: tokens are deducted from every
+-- bucket or from none. KEYS[i] is bucket i and KEYS[n + i] its override; its
+-- rate, capacity and cost are ARGV[3 + (i-1)*3 .. 5 + (i-1)*3]. ARGV[1] is
+-- the shared clock and ARGV[2] is 1 if a bucket may borrow its shortfall from
+-- the bucket before it.
+local now = tonumber(ARGV[1])
+local borrow = tonumber(ARGV[2]) == 1
+local n = #KEYS / 2
+
+local tokens = {}
+local rates = {}
+local capacities = {}
+local costs = {}
+local overrides = {}
+
+for i = 1, n do
+    local base = 2 + (i - 1) * 3
+    rates[i] = tonumber(ARGV[base + 1])
+    capacities[i] = tonumber(ARGV[base + 2])
+    costs[i] = tonumber(ARGV[base + 3])
+
+    if not (rates[i] and rates[i] > 0 and capacities[i] and capacities[i] > 0) then
+        return redis.error_reply('INVALIDLIMIT rate and capacity must be positive')
+    end
+
+    rates[i], capacities[i], overrides[i] = apply_override(KEYS[n + i], rates[i], capacities[i])
+    if costs[i] > capacities[i] then
+        return cost_exceeds_burst(costs[i], capacities[i])
+    end
+
+    local state = redis.call('HMGET', KEYS[i], 'tokens', 'last_refill')
+    local balance = tonumber(state[1])
+    local last_refill = tonumber(state[2])
+
+    if balance == nil then
+        balance = capacities[i]
+        last_refill = now
+    end
+
+    local elapsed = now - last_refill
+    if elapsed < 0 then
+        elapsed = 0
+    end
+
+    balance = balance + elapsed * rates[i]
+    if balance > capacities[i] then
+        balance = capacities[i]
+    end
+    tokens[i] = balance
+end
+
+-- Work out what each bucket pays, innermost (last) first. Mirrors plan() in
+-- multi.go.
+local deductions = {}
+local short = 0
+local short_need = 0
+local carry = 0
+
+for i = n, 1, -1 do
+    local need = costs[i] + carry
+    carry = 0
+    deductions[i] = 0
+
+    if tokens[i] >= need then
+        deductions[i] = need
+    elseif borrow and i > 1 then
+        deductions[i] = math.max(tokens[i], 0)
+        carry = need - deductions[i]
+    else
+        short = i
+        short_need = need
+    end
+
+    if short > 0 and borrow then
+        break
+    end
+end
+
+if short == 0 then
+    for i = 1, n do
+        local balance = tokens[i] - deductions[i]
+        redis.call('HMSET', KEYS[i], 'tokens', balance, 'last_refill', now)
+
+        local ttl = math.ceil(((capacities[i] - balance) / rates[i]) * 2)
+        if ttl < 1 then
+            ttl = 1
+        end
+        redis.call('EXPIRE', KEYS[i], ttl)
+    end
+else
+    for i = 1, n do
+        deductions[i] = 0
+    end
+end
+
+-- Balances are reported before deduction, followed by the rate, period and
+-- burst of any override (zeros if none), so the client can derive each
+-- decision under the limit that was applied.
+local result = {short - 1, tostring(short_need)}
+for i = 1, n do
+    result[2 + i] = tostring(tokens[i])
+    result[2 + n + i] = tostring(deductions[i])
+
+    local o = overrides[i] or {'0', '0', '0'}
+    for j = 1, 3 do
+        result[2 + 2 * n + (i - 1) * 3 + j] = o[j]
+    end
+end
+return result
@@ -0,0 +1,35 @@
+-- Read-only companion to token_bucket.lua: reports the refilled balance, and
+-- the seconds until the bucket is full and until the next token, without
+-- writing anything back. The times are computed here because an override may
+-- change the rate.
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+if not (rate and rate > 0 and capacity and capacity > 0) then
+    return redis.error_reply('INVALIDLIMIT rate and capacity must be positive')
+end
+
+rate, capacity = apply_override(KEYS[2], rate, capacity)
+
+local state = redis.call('HMGET', key, 'tokens', 'last_refill')
+local tokens = tonumber(state[1])
+local last_refill = tonumber(state[2])
+
+if tokens == nil then
+    return {tostring(capacity), '0', '0'}
+end
+
+local elapsed = now - last_refill
+if elapsed < 0 then
+    elapsed = 0
+end
+
+tokens = tokens + elapsed * rate
+
+if tokens > capacity then
+    tokens = capacity
+end
+
+return {tostring(tokens), tostring(math.max(capacity - tokens, 0) / rate), tostring(math.max(1 - tokens, 0) / rate)}
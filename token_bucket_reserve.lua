@@ -0,0 +1,60 @@
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local max_wait = tonumber(ARGV[5])
+
+if not (rate and rate > 0 and capacity and capacity > 0) then
+    return redis.error_reply('INVALIDLIMIT rate and capacity must be positive')
+end
+if not (cost and cost >= 1 and cost <= capacity) then
+    return redis.error_reply('INVALIDLIMIT cost must be between 1 and capacity')
+end
+
+rate, capacity = apply_override(KEYS[2], rate, capacity)
+if cost > capacity then
+    return cost_exceeds_burst(cost, capacity)
+end
+
+local state = redis.call('HMGET', key, 'tokens', 'last_refill')
+local tokens = tonumber(state[1])
+local last_refill = tonumber(state[2])
+
+if tokens == nil then
+    tokens = capacity
+    last_refill = now
+end
+
+local elapsed = now - last_refill
+if elapsed < 0 then
+    elapsed = 0
+end
+
+tokens = tokens + elapsed * rate
+
+if tokens > capacity then
+    tokens = capacity
+end
+
+local wait = 0
+if tokens < cost then
+    wait = (cost - tokens) / rate
+end
+
+-- Refuse without touching the bucket if the caller cannot wait that long.
+if wait > max_wait then
+    return {0, tostring(wait)}
+end
+
+-- Reservations may push the bucket into debt; later callers wait it off.
+tokens = tokens - cost
+redis.call('HMSET', key, 'tokens', tokens, 'last_refill', now)
+
+local ttl = math.ceil(((capacity - tokens) / rate) * 2)
+if ttl < 1 then
+    ttl = 1
+end
+redis.call('EXPIRE', key, ttl)
+
+return {1, tostring(wait)}
@@ -0,0 +1,39 @@
+package limiter
+
+import "time"
+
+// windowOf locates t within the epoch-aligned windows of length period,
+// returning the window's index and how far into it t is. Aligning windows to
+// the epoch means every instance agrees on where a window starts.
+func windowOf(t time.Time, period time.Duration) (index int64, elapsed time.Duration) {
+	ns := t.UnixNano()
+	p := period.Nanoseconds()
+	return ns / p, time.Duration(ns % p)
+}
+
+// slidingEstimate approximates the number of events in the rolling window
+// ending elapsed into the current window, by weighting the previous window's
+// count by how much of it the rolling window still overlaps.
+func slidingEstimate(period time.Duration, previous, current int64, elapsed time.Duration) float64 {
+	weight := 1 - float64(elapsed)/float64(period)
+	return float64(previous)*weight + float64(current)
+}
+
+// slidingWait returns how long until n more events fit under limit.Rate in the
+// sliding window counter, given the counts of the previous and current
+// windows. The sliding_window.lua script uses the same formula.
+func slidingWait(limit Limit, previous, current, n int64, elapsed time.Duration) time.Duration {
+	period := float64(limit.Period)
+	rate := float64(limit.Rate)
+
+	if current+n > limit.Rate {
+		// The current window alone is too full: wait for the next window, in
+		// which today's count becomes the decaying previous count.
+		next := period * (1 - (rate-float64(n))/float64(current))
+		return time.Duration(period - float64(elapsed) + max(next, 0))
+	}
+
+	// Otherwise wait for enough of the previous window to slide out.
+	wait := period*(1-(rate-float64(current+n))/float64(previous)) - float64(elapsed)
+	return time.Duration(max(wait, 0))
+}
@@ -0,0 +1,166 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// alignToWindow sleeps until just after the start of the next window of
+// period, so tests of window-based limiters are not split by a boundary.
+func alignToWindow(period time.Duration) {
+	_, elapsed := windowOf(time.Now(), period)
+	time.Sleep(period - elapsed + 5*time.Millisecond)
+}
+
+func testFixedWindow(t *testing.T, l RateLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 3, Period: 200 * time.Millisecond}
+
+	t.Run("ResetsAtWindowEnd", func(t *testing.T) {
+		id := newID("counter")
+		alignToWindow(limit.Period)
+
+		if dec, err := l.AllowN(ctx, id, limit, 2); err != nil || !dec.Allow || dec.Remaining != 1 {
+			t.Fatalf("Expected 2 events allowed with 1 remaining, got %+v (err %v)", dec, err)
+		}
+		if dec, err := l.Allow(ctx, id, limit); err != nil || !dec.Allow || dec.Remaining != 0 {
+			t.Fatalf("Expected 3rd event allowed with 0 remaining, got %+v (err %v)", dec, err)
+		}
+
+		dec, err := l.Allow(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Fatal("Expected the 4th event in the window to be denied")
+		}
+		if dec.RetryAfter <= 150*time.Millisecond || dec.RetryAfter > limit.Period {
+			t.Errorf("Expected RetryAfter until the window ends, got %v", dec.RetryAfter)
+		}
+
+		time.Sleep(dec.RetryAfter + 5*time.Millisecond)
+
+		// A new window starts empty.
+		if dec, err := l.AllowN(ctx, id, limit, 3); err != nil || !dec.Allow {
+			t.Errorf("Expected the whole window to be available, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("CostExceedsWindow", func(t *testing.T) {
+		if _, err := l.AllowN(ctx, newID("bulk"), limit, 4); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+		}
+	})
+}
+
+func testSlidingWindow(t *testing.T, l RateLimiter, newID func(string) Identity) {
+	ctx := context.Background()
+	limit := Limit{Rate: 4, Period: 200 * time.Millisecond}
+
+	t.Run("WeightsPreviousWindow", func(t *testing.T) {
+		id := newID("counter")
+		alignToWindow(limit.Period)
+
+		if dec, err := l.AllowN(ctx, id, limit, 4); err != nil || !dec.Allow || dec.Remaining != 0 {
+			t.Fatalf("Expected 4 events allowed with 0 remaining, got %+v (err %v)", dec, err)
+		}
+
+		dec, err := l.Allow(ctx, id, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec.Allow {
+			t.Fatal("Expected the 5th event to be denied")
+		}
+		// The window ends in ~195ms, after which another quarter of the
+		// previous count must slide out (~50ms).
+		if dec.RetryAfter <= 200*time.Millisecond || dec.RetryAfter > 250*time.Millisecond {
+			t.Errorf("Expected RetryAfter past the window end, got %v", dec.RetryAfter)
+		}
+
+		// Unlike a fixed window, the previous count still weighs in just
+		// after the boundary.
+		alignToWindow(limit.Period)
+		if dec, err := l.Allow(ctx, id, limit); err != nil || dec.Allow {
+			t.Fatalf("Expected the previous window to still count, got %+v (err %v)", dec, err)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		if dec, err := l.Allow(ctx, id, limit); err != nil || !dec.Allow {
+			t.Errorf("Expected a slot once a quarter of the previous window slid out, got %+v (err %v)", dec, err)
+		}
+	})
+
+	t.Run("CostExceedsWindow", func(t *testing.T) {
+		if _, err := l.AllowN(ctx, newID("bulk"), limit, 5); !errors.Is(err, ErrCostExceedsBurst) {
+			t.Errorf("Expected ErrCostExceedsBurst, got %v", err)
+		}
+	})
+}
+
+func TestSlidingWait(t *testing.T) {
+	limit := Limit{Rate: 10, Period: time.Second}
+
+	tests := []struct {
+		name              string
+		previous, current int64
+		elapsed           time.Duration
+		want              time.Duration
+	}{
+		{"PreviousSlidesOut", 10, 5, 400 * time.Millisecond, 200 * time.Millisecond},
+		{"CurrentFull", 0, 10, 400 * time.Millisecond, 700 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slidingWait(limit, tt.previous, tt.current, 1, tt.elapsed)
+			if diff := got - tt.want; diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+
+			// Waiting that long must admit the event.
+			_, later := windowOf(time.Unix(0, int64(tt.elapsed+got)), limit.Period)
+			previous, current := tt.previous, tt.current
+			if tt.elapsed+got >= limit.Period {
+				previous, current = current, 0
+			}
+			if estimate := slidingEstimate(limit.Period, previous, current, later); estimate+1 > float64(limit.Rate)+1e-9 {
+				t.Errorf("Expected room after waiting, estimate is %v", estimate)
+			}
+		})
+	}
+}
+
+func TestMemoryFixedWindowLimiter(t *testing.T) {
+	testFixedWindow(t, NewMemoryFixedWindowLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestMemorySlidingWindowLimiter(t *testing.T) {
+	testSlidingWindow(t, NewMemorySlidingWindowLimiter(), func(key string) Identity {
+		return Identity{Namespace: "test", Key: key}
+	})
+}
+
+func TestRedisFixedWindowLimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisFixedWindowLimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisFixedWindowLimiter: %v", err)
+	}
+	testFixedWindow(t, limiter, uniqueIdentity)
+}
+
+func TestRedisSlidingWindowLimiter(t *testing.T) {
+	_, client := newIntegrationLimiter(t)
+
+	limiter, err := NewRedisSlidingWindowLimiter(client)
+	if err != nil {
+		t.Fatalf("Failed to create RedisSlidingWindowLimiter: %v", err)
+	}
+	testSlidingWindow(t, limiter, uniqueIdentity)
+}